@@ -0,0 +1,138 @@
+package gale
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AccessLogFormat is the line format of the access log
+type AccessLogFormat int
+
+const (
+	// CommonLogFormat is the NCSA Common Log Format
+	CommonLogFormat AccessLogFormat = iota
+	// CombinedLogFormat is the Common Log Format with the referer and user agent
+	CombinedLogFormat
+	// JSONLogFormat writes every request as a JSON line
+	JSONLogFormat
+)
+
+// accessLogger writes the access log lines of an application
+type accessLogger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format AccessLogFormat
+}
+
+func (l *accessLogger) log(c Ctx, start time.Time) error {
+	line := formatAccessLog(c, l.format, start)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err := l.w.Write(line)
+	return err
+}
+
+// AccessLog writes a line for every request of the application to w after the response is sent,
+// including the not found, method not allowed and automatic OPTIONS responses.
+func (g *Gale) AccessLog(w io.Writer, format AccessLogFormat) {
+	g.accessLoggers = append(g.accessLoggers, &accessLogger{w: w, format: format})
+}
+
+func (g *Gale) logAccess(c Ctx, start time.Time) {
+	for _, l := range g.accessLoggers {
+		if err := l.log(c, start); err != nil {
+			c.Logger().Error("failed to write the access log", "error", err)
+		}
+	}
+}
+
+// AccessLog creates a middleware that writes a line for every request of the routes it is used on to w.
+// The line is written after the response is sent, so the status and size include the error responses.
+// Use Gale.AccessLog to log the requests that do not match a route too.
+func AccessLog(w io.Writer, format AccessLogFormat) MiddlewareFunc {
+	l := &accessLogger{w: w, format: format}
+
+	return func(c Ctx) error {
+		start := time.Now()
+		c.onFinish(func() {
+			if err := l.log(c, start); err != nil {
+				c.Logger().Error("failed to write the access log", "error", err)
+			}
+		})
+		return c.Next()
+	}
+}
+
+func formatAccessLog(c Ctx, format AccessLogFormat, start time.Time) []byte {
+	r := c.Request()
+
+	status := c.Response().Status()
+	if status == 0 {
+		status = 200
+	}
+
+	host := c.IP()
+	if host == "" {
+		host = r.RemoteAddr
+	}
+
+	user := "-"
+	if r.URL.User != nil && r.URL.User.Username() != "" {
+		user = r.URL.User.Username()
+	} else if name, _, ok := r.BasicAuth(); ok && name != "" {
+		user = name
+	}
+
+	if format == JSONLogFormat {
+		b, _ := json.Marshal(accessLogEntry{
+			Time:      start,
+			RequestID: c.ID(),
+			IP:        host,
+			User:      user,
+			Method:    r.Method,
+			Path:      r.URL.RequestURI(),
+			Proto:     r.Proto,
+			Status:    status,
+			Size:      c.Response().Size(),
+			Duration:  time.Since(start).Seconds() * 1000,
+			Referer:   r.Referer(),
+			UserAgent: r.UserAgent(),
+		})
+		return append(b, '\n')
+	}
+
+	size := "-"
+	if n := c.Response().Size(); n > 0 {
+		size = strconv.FormatInt(n, 10)
+	}
+
+	line := fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s",
+		host, user, start.Format("02/Jan/2006:15:04:05 -0700"), r.Method, r.URL.RequestURI(), r.Proto, status, size)
+
+	if format == CombinedLogFormat {
+		line += fmt.Sprintf(" %q %q", r.Referer(), r.UserAgent())
+	}
+
+	return []byte(line + "\n")
+}
+
+type accessLogEntry struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id"`
+	IP        string    `json:"ip"`
+	User      string    `json:"user"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Proto     string    `json:"proto"`
+	Status    int       `json:"status"`
+	Size      int64     `json:"size"`
+	Duration  float64   `json:"duration_ms"`
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
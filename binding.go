@@ -0,0 +1,483 @@
+package gale
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	timeType            = reflect.TypeFor[time.Time]()
+	fileHeaderType      = reflect.TypeFor[*multipart.FileHeader]()
+	fileHeadersType     = reflect.TypeFor[[]*multipart.FileHeader]()
+)
+
+// timeLayouts are the accepted time formats, RFC 3339 and the formats of the HTML date and time inputs
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+// paramCacheKey is the key of a converted route param in the request
+type paramCacheKey struct {
+	name string
+	typ  reflect.Type
+}
+
+// cachedParam is a converted route param with the value it was converted from,
+// so the cache is not used after the params are changed
+type cachedParam struct {
+	raw   string
+	value any
+}
+
+// Param returns the route param converted to T.
+// It supports strings, numbers, bools, time.Duration, time.Time, uuid.UUID and any encoding.TextUnmarshaler.
+// Conversions are cached in the request, so middlewares and handlers can call it without parsing the value again,
+// the values converted by the int, bool and uuid route validators are reused as int, bool and uuid.UUID.
+func Param[T any](c Ctx, name string) (T, error) {
+	var out T
+
+	value, ok := c.Params()[name]
+	if !ok {
+		return out, NewError(http.StatusBadRequest, "missing param '"+name+"'")
+	}
+
+	key := paramCacheKey{name: name, typ: reflect.TypeFor[T]()}
+	cache := c.paramCache()
+	if cached, ok := cache[key]; ok && cached.raw == value {
+		return cached.value.(T), nil
+	}
+
+	if err := setValue(reflect.ValueOf(&out).Elem(), []string{value}); err != nil {
+		return out, NewError(http.StatusBadRequest, fmt.Sprintf("invalid param '%s': %v", name, err))
+	}
+
+	cache[key] = cachedParam{raw: value, value: out}
+	return out, nil
+}
+
+// FieldError describes an invalid field of a request
+type FieldError struct {
+	// Field is the name or path of the field, like "page" or "address.city"
+	Field string `json:"field" xml:"field"`
+	// Source is where the field comes from, like query, header, cookie, param, form or json
+	Source string `json:"source,omitempty" xml:"source,omitempty"`
+	// Message describes the problem
+	Message string `json:"message" xml:"message"`
+}
+
+// bindSource is a source of values for the fields with its tag, like `query:"page"`
+type bindSource struct {
+	tag string
+	// values returns the values of a key, it is nil for sources that are decoded separately, like json
+	values func(key string) ([]string, bool)
+	// files returns the uploaded files of a key, it is only set for multipart forms
+	files func(key string) []*multipart.FileHeader
+}
+
+// binder fills the fields of a struct from the sources, earlier sources take precedence.
+// Tags can be marked as required, like `query:"page,required"`, and a `default:"10"` tag is used for missing values.
+type binder struct {
+	sources []bindSource
+}
+
+// bind fills the struct pointer, the returned field errors are the values that are missing or can not be converted
+func (b *binder) bind(dst any) ([]FieldError, error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("bind destination must be a non-nil struct pointer")
+	}
+
+	prefixes := make(map[string]string, len(b.sources))
+	for _, src := range b.sources {
+		prefixes[src.tag] = ""
+	}
+
+	errs, _ := b.bindFields(v.Elem(), prefixes)
+	return errs, nil
+}
+
+// bindFields binds the fields of the struct, nested structs use their name as a prefix, like "address.city".
+// Only the sources in prefixes can reach the struct. It reports whether any field had a value.
+func (b *binder) bindFields(v reflect.Value, prefixes map[string]string) ([]FieldError, bool) {
+	var (
+		errs  []FieldError
+		bound bool
+	)
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldErrs, fieldBound := b.bindField(v.Field(i), field, prefixes)
+		errs, bound = append(errs, fieldErrs...), bound || fieldBound
+	}
+
+	return errs, bound
+}
+
+func (b *binder) bindField(v reflect.Value, field reflect.StructField, prefixes map[string]string) ([]FieldError, bool) {
+	var (
+		errs     []FieldError
+		bound    bool
+		required bool
+		first    *FieldError
+		nested   = isNestedStruct(field.Type)
+		children = map[string]string{}
+	)
+
+	for _, src := range b.sources {
+		prefix, ok := prefixes[src.tag]
+		if !ok {
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup(src.tag)
+		name, opts, _ := strings.Cut(tag, ",")
+		if !ok || name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		key := prefix + name
+
+		if first == nil {
+			first = &FieldError{Field: key, Source: src.tag}
+		}
+		required = required || slices.Contains(strings.Split(opts, ","), "required")
+
+		if nested {
+			children[src.tag] = key + "."
+			continue
+		}
+
+		if bound || src.values == nil {
+			continue
+		}
+
+		if ok, err := b.bindValue(v, src, key); ok {
+			bound = true
+			if err != nil {
+				errs = append(errs, FieldError{Field: key, Source: src.tag, Message: err.Error()})
+			}
+		}
+	}
+
+	// embedded structs without tags are bound with the fields of the parent
+	if first == nil && field.Anonymous && nested {
+		children = prefixes
+	}
+
+	if nested && len(children) > 0 {
+		nestedErrs, nestedBound := b.bindNested(v, children)
+		errs, bound = append(errs, nestedErrs...), nestedBound
+	}
+
+	if first == nil {
+		return errs, bound
+	}
+
+	if def, ok := field.Tag.Lookup("default"); ok && !bound && v.IsZero() {
+		values := []string{def}
+		if v.Kind() == reflect.Slice {
+			values = strings.Split(def, ",")
+		}
+
+		if err := setValue(v, values); err != nil {
+			errs = append(errs, FieldError{Field: first.Field, Source: first.Source, Message: "invalid default: " + err.Error()})
+		}
+	}
+
+	if required && !bound && v.IsZero() {
+		first.Message = "required"
+		errs = append(errs, *first)
+	}
+
+	return errs, bound
+}
+
+// bindValue sets the field from the values of the source, it reports whether the source had the key
+func (b *binder) bindValue(v reflect.Value, src bindSource, key string) (bool, error) {
+	if v.Type() == fileHeaderType || v.Type() == fileHeadersType {
+		if src.files == nil {
+			return false, nil
+		}
+
+		files := src.files(key)
+		if len(files) == 0 {
+			return false, nil
+		}
+
+		if v.Type() == fileHeaderType {
+			v.Set(reflect.ValueOf(files[0]))
+		} else {
+			v.Set(reflect.ValueOf(files))
+		}
+		return true, nil
+	}
+
+	values, ok := src.values(key)
+	if !ok || len(values) == 0 {
+		return false, nil
+	}
+
+	return true, setValue(v, values)
+}
+
+// bindNested binds a nested struct, pointers to structs are only set when one of their fields has a value
+func (b *binder) bindNested(v reflect.Value, prefixes map[string]string) ([]FieldError, bool) {
+	if v.Kind() != reflect.Pointer {
+		return b.bindFields(v, prefixes)
+	}
+
+	if !v.IsNil() {
+		return b.bindFields(v.Elem(), prefixes)
+	}
+
+	nested := reflect.New(v.Type().Elem())
+	errs, bound := b.bindFields(nested.Elem(), prefixes)
+	if bound {
+		v.Set(nested)
+	}
+	return errs, bound
+}
+
+// isNestedStruct reports whether the type is a struct, or a pointer to one, that is bound field by field
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType && t != fileHeaderType.Elem() && !isTextUnmarshaler(t)
+}
+
+// setValue converts the string values to the type of v, slices get every value, other types the first one
+func setValue(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), values)
+	}
+
+	if v.Kind() == reflect.Slice && !isTextUnmarshaler(v.Type()) && v.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	return setString(v, values[0])
+}
+
+func setString(v reflect.Value, value string) error {
+	switch {
+	case v.Type() == timeType:
+		t, err := parseTime(value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case isTextUnmarshaler(v.Type()):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("invalid bool")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("invalid integer")
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("invalid unsigned integer")
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return errors.New("invalid number")
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		// []byte
+		v.SetBytes([]byte(value))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func isTextUnmarshaler(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid time")
+}
+
+// bodyTags are the tags of the fields that Ctx.Bind sets from a decoded body
+var bodyTags = []string{"json", "xml"}
+
+// decodeBody decodes the body into a copy of the struct and keeps only the fields with a body tag,
+// so decoders that match untagged field names, like encoding/json, can not set the fields of the other sources
+func decodeBody(dst any, decode func(v any) error) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return decode(dst)
+	}
+
+	decoded := reflect.New(v.Elem().Type())
+	decoded.Elem().Set(v.Elem())
+	if err := decode(decoded.Interface()); err != nil {
+		return err
+	}
+
+	copyBodyFields(v.Elem(), decoded.Elem())
+	return nil
+}
+
+// copyBodyFields copies the fields with a body tag, and the ones of the untagged embedded structs
+func copyBodyFields(dst, src reflect.Value) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !dst.Field(i).CanSet() {
+			continue
+		}
+
+		switch {
+		case hasBodyTag(field):
+			dst.Field(i).Set(src.Field(i))
+		case field.Anonymous && field.Type.Kind() == reflect.Struct:
+			copyBodyFields(dst.Field(i), src.Field(i))
+		}
+	}
+}
+
+func hasBodyTag(field reflect.StructField) bool {
+	for _, tag := range bodyTags {
+		if value, ok := field.Tag.Lookup(tag); ok && value != "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// bindError converts the field errors into a 400 error that lists every invalid field
+func bindError(errs []FieldError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = fmt.Sprintf("invalid %s '%s': %s", e.Source, e.Field, e.Message)
+	}
+
+	return &Error{
+		Err:    strings.Join(msgs, "; "),
+		Status: http.StatusBadRequest,
+		Fields: errs,
+	}
+}
+
+// paramSource returns the route params of the request
+func paramSource(c Ctx) bindSource {
+	return bindSource{tag: "param", values: func(key string) ([]string, bool) {
+		value, ok := c.Params()[key]
+		return []string{value}, ok
+	}}
+}
+
+// formSource parses the urlencoded or multipart form of the request
+func formSource(r *http.Request, multipartMemory int64) (bindSource, error) {
+	src := bindSource{tag: "form"}
+
+	if mediaType(r.Header.Get("Content-Type")) == ContentTypeMultipart {
+		if err := r.ParseMultipartForm(multipartMemory); err != nil {
+			return src, bodyError(err)
+		}
+
+		src.values = func(key string) ([]string, bool) {
+			values, ok := r.MultipartForm.Value[key]
+			return values, ok
+		}
+		src.files = func(key string) []*multipart.FileHeader {
+			return r.MultipartForm.File[key]
+		}
+		return src, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return src, bodyError(err)
+	}
+
+	src.values = func(key string) ([]string, bool) {
+		values, ok := r.PostForm[key]
+		return values, ok
+	}
+	return src, nil
+}
+
+// requestSources returns the query, header and cookie values of the request
+func requestSources(r *http.Request) []bindSource {
+	query := r.URL.Query()
+
+	return []bindSource{
+		{tag: "query", values: func(key string) ([]string, bool) {
+			values, ok := query[key]
+			return values, ok
+		}},
+		{tag: "header", values: func(key string) ([]string, bool) {
+			values := r.Header.Values(key)
+			return values, len(values) > 0
+		}},
+		{tag: "cookie", values: func(key string) ([]string, bool) {
+			cookie, err := r.Cookie(key)
+			if err != nil {
+				return nil, false
+			}
+			return []string{cookie.Value}, true
+		}},
+	}
+}
@@ -0,0 +1,429 @@
+package gale
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParam(t *testing.T) {
+	app := New()
+
+	id := uuid.New()
+	r := httptest.NewRequest(http.MethodGet, "/users/12/"+id.String()+"/2024-05-01/true/1.5", nil)
+	c := app.NewTestContext(httptest.NewRecorder(), r, "/users/{id@int}/{uuid}/{date}/{active}/{ratio}")
+
+	n, err := Param[int64](c, "id")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), n)
+
+	u, err := Param[uint](c, "id")
+	assert.Nil(t, err)
+	assert.Equal(t, uint(12), u)
+
+	parsed, err := Param[uuid.UUID](c, "uuid")
+	assert.Nil(t, err)
+	assert.Equal(t, id, parsed)
+
+	date, err := Param[time.Time](c, "date")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), date)
+
+	active, err := Param[bool](c, "active")
+	assert.Nil(t, err)
+	assert.True(t, active)
+
+	ratio, err := Param[float64](c, "ratio")
+	assert.Nil(t, err)
+	assert.Equal(t, 1.5, ratio)
+
+	_, err = Param[int](c, "uuid")
+	var e *Error
+	if assert.ErrorAs(t, err, &e) {
+		assert.Equal(t, http.StatusBadRequest, e.Status)
+	}
+
+	_, err = Param[int](c, "missing")
+	assert.Error(t, err)
+
+	// the cached value is not used after the param is changed
+	c.Params()["id"] = "13"
+	n, _ = Param[int64](c, "id")
+	assert.Equal(t, int64(13), n)
+
+	var params struct {
+		ID     int        `param:"id"`
+		UUID   uuid.UUID  `param:"uuid"`
+		Date   *time.Time `param:"date"`
+		Active bool       `param:"active"`
+		Ignore string
+	}
+	assert.Nil(t, c.BindParams(&params))
+	assert.Equal(t, 13, params.ID)
+	assert.Equal(t, id, params.UUID)
+	assert.Equal(t, date, *params.Date)
+	assert.True(t, params.Active)
+
+	var bad struct {
+		ID int `param:"uuid"`
+	}
+	err = c.BindParams(&bad)
+	if assert.ErrorAs(t, err, &e) {
+		assert.Equal(t, http.StatusBadRequest, e.Status)
+		assert.Contains(t, e.Error(), "invalid param 'uuid'")
+	}
+
+	assert.Error(t, c.BindParams(bad))
+}
+
+func TestParamValidatorCache(t *testing.T) {
+	app := New()
+
+	var cached []any
+	app.Get("/users/{id@int,min:1}/{uuid@uuid}/{name}", func(c Ctx) error {
+		for _, key := range []paramCacheKey{
+			{name: "id", typ: reflect.TypeFor[int]()},
+			{name: "uuid", typ: reflect.TypeFor[uuid.UUID]()},
+			{name: "name", typ: reflect.TypeFor[string]()},
+		} {
+			cached = append(cached, c.paramCache()[key].value)
+		}
+
+		id, err := Param[int](c, "id")
+		if err != nil {
+			return err
+		}
+		return c.SendString(strconv.Itoa(id))
+	})
+
+	id := uuid.New()
+	w := httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/12/"+id.String()+"/john", nil))
+	assert.Equal(t, "12", w.Body.String())
+	assert.Equal(t, []any{12, id, nil}, cached)
+}
+
+type testColor struct {
+	r, g, b uint8
+}
+
+func (c *testColor) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "#%02x%02x%02x", &c.r, &c.g, &c.b)
+	return err
+}
+
+func TestParseForm(t *testing.T) {
+	type address struct {
+		City string `form:"city"`
+		Zip  *int   `form:"zip"`
+	}
+
+	type request struct {
+		Name     string                  `form:"name"`
+		Age      int                     `form:"age"`
+		Tags     []string                `form:"tag"`
+		Nick     *string                 `form:"nick"`
+		Born     time.Time               `form:"born"`
+		Color    testColor               `form:"color"`
+		Address  address                 `form:"address"`
+		Billing  *address                `form:"billing"`
+		Avatar   *multipart.FileHeader   `form:"avatar"`
+		Photos   []*multipart.FileHeader `form:"photos"`
+		Internal string                  `form:"-"`
+	}
+
+	app := New()
+
+	form := url.Values{
+		"name":         {"John"},
+		"age":          {"42"},
+		"tag":          {"a", "b"},
+		"born":         {"1990-01-02"},
+		"color":        {"#ff8000"},
+		"address.city": {"Budapest"},
+		"address.zip":  {"1011"},
+		"Internal":     {"x"},
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", ContentTypeForm+"; charset=utf-8")
+	c := app.NewTestContext(httptest.NewRecorder(), r)
+
+	var req request
+	assert.Nil(t, c.Body().Parse(&req))
+	assert.Equal(t, "John", req.Name)
+	assert.Equal(t, 42, req.Age)
+	assert.Equal(t, []string{"a", "b"}, req.Tags)
+	assert.Nil(t, req.Nick)
+	assert.Equal(t, time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC), req.Born)
+	assert.Equal(t, testColor{0xff, 0x80, 0x00}, req.Color)
+	assert.Equal(t, "Budapest", req.Address.City)
+	assert.Equal(t, 1011, *req.Address.Zip)
+	assert.Nil(t, req.Billing)
+	assert.Empty(t, req.Internal)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("name", "Jane")
+	_ = mw.WriteField("billing.city", "Vienna")
+	for _, name := range []string{"avatar", "photos", "photos"} {
+		fw, _ := mw.CreateFormFile(name, name+".png")
+		_, _ = fw.Write([]byte("png"))
+	}
+	_ = mw.Close()
+
+	r = httptest.NewRequest(http.MethodPost, "/", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	c = app.NewTestContext(httptest.NewRecorder(), r)
+
+	req = request{}
+	assert.Nil(t, c.Body().Parse(&req))
+	assert.Equal(t, "Jane", req.Name)
+	assert.Equal(t, "Vienna", req.Billing.City)
+	if assert.NotNil(t, req.Avatar) {
+		assert.Equal(t, "avatar.png", req.Avatar.Filename)
+	}
+	assert.Len(t, req.Photos, 2)
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("age=old&address.zip=x"))
+	r.Header.Set("Content-Type", ContentTypeForm)
+	c = app.NewTestContext(httptest.NewRecorder(), r)
+
+	err := c.Body().ParseForm(&req)
+	var e *Error
+	if assert.ErrorAs(t, err, &e) {
+		assert.Equal(t, http.StatusBadRequest, e.Status)
+		assert.Contains(t, e.Error(), "'age'")
+		assert.Contains(t, e.Error(), "'address.zip'")
+	}
+}
+
+func TestBind(t *testing.T) {
+	type request struct {
+		ID     int      `param:"id"`
+		Page   int      `query:"page" default:"1"`
+		Limit  int      `query:"limit" default:"10"`
+		Sort   []string `query:"sort" default:"name,age"`
+		Token  string   `header:"X-Token,required"`
+		Theme  string   `cookie:"theme"`
+		Name   string   `json:"name,required"`
+		Email  string   `json:"email"`
+		Search string   `query:"q" form:"q"`
+	}
+
+	app := New()
+
+	var req request
+	app.Post("/users/{id}", func(c Ctx) error {
+		req = request{}
+		return c.Bind(&req)
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/users/12?limit=50&q=query", strings.NewReader(`{"name":"John","email":"john@example.com"}`))
+	r.Header.Set("Content-Type", ContentTypeJSON)
+	r.Header.Set("X-Token", "secret")
+	r.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+	w := httptest.NewRecorder()
+	app.server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, request{
+		ID:     12,
+		Page:   1,
+		Limit:  50,
+		Sort:   []string{"name", "age"},
+		Token:  "secret",
+		Theme:  "dark",
+		Name:   "John",
+		Email:  "john@example.com",
+		Search: "query",
+	}, req)
+
+	r = httptest.NewRequest(http.MethodPost, "/users/x?page=first", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", ContentTypeJSON)
+
+	w = httptest.NewRecorder()
+	app.server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var body struct {
+		Fields []FieldError `json:"fields"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.ElementsMatch(t, []FieldError{
+		{Field: "id", Source: "param", Message: "invalid integer"},
+		{Field: "page", Source: "query", Message: "invalid integer"},
+		{Field: "X-Token", Source: "header", Message: "required"},
+		{Field: "name", Source: "json", Message: "required"},
+	}, body.Fields)
+
+	r = httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader("q=form"))
+	r.Header.Set("Content-Type", ContentTypeForm)
+	r.Header.Set("X-Token", "secret")
+
+	w = httptest.NewRecorder()
+	app.server.ServeHTTP(w, r)
+	assert.Equal(t, "form", req.Search)
+
+	type scoped struct {
+		UserID string `header:"X-User-ID"`
+		Role   string
+		Name   string `json:"name"`
+	}
+
+	var sc scoped
+	app.Post("/scoped", func(c Ctx) error {
+		sc = scoped{}
+		return c.Bind(&sc)
+	})
+
+	r = httptest.NewRequest(http.MethodPost, "/scoped", strings.NewReader(`{"userid":"admin","role":"admin","name":"John"}`))
+	r.Header.Set("Content-Type", ContentTypeJSON)
+
+	w = httptest.NewRecorder()
+	app.server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, scoped{Name: "John"}, sc)
+}
+
+func TestBodyLimit(t *testing.T) {
+	app := New(&Config{BodyLimit: 16, StrictJSON: true})
+
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	parse := func(c Ctx) error {
+		var req request
+		if err := c.Body().Parse(&req); err != nil {
+			return err
+		}
+		return c.SendString(req.Name)
+	}
+
+	app.Post("/", parse)
+	app.Post("/upload", parse).BodyLimit(1 << 10)
+	app.Post("/signed", func(c Ctx) error {
+		raw, err := c.Body().Bytes()
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := c.Body().ParseJSON(&req); err != nil {
+			return err
+		}
+
+		again, _ := c.Body().Bytes()
+		return c.SendString(fmt.Sprintf("%d %s %t", len(raw), req.Name, bytes.Equal(raw, again)))
+	})
+
+	send := func(path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", ContentTypeJSON)
+
+		w := httptest.NewRecorder()
+		app.server.ServeHTTP(w, r)
+		return w
+	}
+
+	w := send("/", `{"name":"John"}`)
+	assert.Equal(t, "John", w.Body.String())
+
+	long := `{"name":"` + strings.Repeat("a", 32) + `"}`
+
+	w = send("/", long)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = send("/upload", long)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = send("/", `{"nick":"x"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	for _, body := range []string{`{"name":"a"}{}`, `{"name":"a"}}`, `{"name":"a"}]`, `{"name":"a"} x`} {
+		w = send("/", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	w = send("/", "{\"name\":\"a\"}\n")
+	assert.Equal(t, "a", w.Body.String())
+
+	w = send("/signed", `{"name":"Jane"}`)
+	assert.Equal(t, "15 Jane true", w.Body.String())
+
+	sub := New(&Config{BodyLimit: 32})
+	sub.Post("/", parse)
+	sub.Post("/unlimited", parse).BodyLimit(-1)
+	app.MountApp("/sub", sub)
+
+	w = send("/sub", long)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = send("/sub/unlimited", long)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestParseMediaType(t *testing.T) {
+	app := New(&Config{
+		Decoders: map[string]DecoderFunc{
+			"text/csv": func(r io.Reader, v any) error {
+				record, err := csv.NewReader(r).Read()
+				if err != nil {
+					return err
+				}
+				v.(*struct{ Name string }).Name = record[0]
+				return nil
+			},
+		},
+	})
+
+	app.Post("/", func(c Ctx) error {
+		var req struct{ Name string }
+		if err := c.Body().Parse(&req); err != nil {
+			return err
+		}
+		return c.SendString(req.Name)
+	})
+
+	tests := []struct {
+		contentType string
+		body        string
+		status      int
+		name        string
+	}{
+		{"application/json; charset=utf-8", `{"Name":"json"}`, http.StatusOK, "json"},
+		{"application/vnd.api+json", `{"Name":"api"}`, http.StatusOK, "api"},
+		{"application/atom+xml", `<entry><Name>atom</Name></entry>`, http.StatusOK, "atom"},
+		{"Text/CSV; header=absent", "csv,1", http.StatusOK, "csv"},
+		{"application/msgpack", "x", http.StatusUnsupportedMediaType, ""},
+		{"", "x", http.StatusUnsupportedMediaType, ""},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", tt.contentType)
+
+		w := httptest.NewRecorder()
+		app.server.ServeHTTP(w, r)
+
+		assert.Equal(t, tt.status, w.Code, tt.contentType)
+		if tt.name != "" {
+			assert.Equal(t, tt.name, w.Body.String(), tt.contentType)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package gale
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"mime"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	contentTypeCSV  = "text/csv"
+	contentTypeYAML = "application/yaml"
+)
+
+// DecoderFunc decodes a request body into v, decoders are registered by media type in Config.Decoders
+type DecoderFunc func(r io.Reader, v any) error
+
+// defaultDecoders returns the JSON and XML decoders
+func defaultDecoders(strictJSON bool) map[string]DecoderFunc {
+	return map[string]DecoderFunc{
+		ContentTypeJSON: func(r io.Reader, v any) error {
+			return decodeJSON(r, v, strictJSON)
+		},
+		ContentTypeXML: decodeXML,
+		"text/xml":     decodeXML,
+	}
+}
+
+// EncoderFunc encodes a response of Ctx.Format, encoders are registered by media type in Config.Encoders
+type EncoderFunc func(w io.Writer, v any) error
+
+// defaultEncoderTypes are the media types of the default encoders in the order of preference of Ctx.Format
+var defaultEncoderTypes = []string{ContentTypeJSON, ContentTypeText, ContentTypeHTML, ContentTypeXML, contentTypeCSV, contentTypeYAML}
+
+// defaultEncoders returns the JSON, text, HTML, XML, CSV and YAML encoders
+func defaultEncoders() map[string]EncoderFunc {
+	return map[string]EncoderFunc{
+		ContentTypeJSON: encodeJSON,
+		ContentTypeText: encodeText,
+		ContentTypeHTML: encodeHTML,
+		ContentTypeXML:  encodeXML,
+		contentTypeCSV:  encodeCSV,
+		contentTypeYAML: encodeYAML,
+	}
+}
+
+// encoders are the response encoders of the application with their media types in the order of preference.
+// Registering an encoder replaces the map and the types, so the ones read by Ctx.Format are never modified.
+type encoders struct {
+	mu    sync.RWMutex
+	fns   map[string]EncoderFunc
+	types []string
+}
+
+func newEncoders(fns map[string]EncoderFunc) *encoders {
+	fns = maps.Clone(fns)
+	return &encoders{fns: fns, types: encoderTypes(fns)}
+}
+
+// get returns the encoders and their media types, they must not be modified
+func (e *encoders) get() (map[string]EncoderFunc, []string) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.fns, e.types
+}
+
+// RegisterEncoder registers a response encoder of Ctx.Format by media type, it replaces the encoder of the same type.
+// It is safe to call while the application is serving requests.
+func (g *Gale) RegisterEncoder(mediaType string, fn EncoderFunc) {
+	g.encoders.mu.Lock()
+	defer g.encoders.mu.Unlock()
+
+	fns := maps.Clone(g.encoders.fns)
+	fns[mediaType] = fn
+	g.encoders.fns, g.encoders.types = fns, encoderTypes(fns)
+}
+
+// encoderTypes returns the media types of the encoders, the default ones first, then the custom ones in alphabetical order
+func encoderTypes(encoders map[string]EncoderFunc) []string {
+	var custom []string
+	for mt := range encoders {
+		if !slices.Contains(defaultEncoderTypes, mt) {
+			custom = append(custom, mt)
+		}
+	}
+	sort.Strings(custom)
+
+	var types []string
+	for _, mt := range defaultEncoderTypes {
+		if _, ok := encoders[mt]; ok {
+			types = append(types, mt)
+		}
+	}
+	return append(types, custom...)
+}
+
+// decoder returns the decoder of the media type.
+// Types with a structured syntax suffix, like "application/vnd.api+json", fall back to the decoder of the suffix.
+func (c *Config) decoder(mt string) (DecoderFunc, bool) {
+	if dec, ok := c.Decoders[mt]; ok {
+		return dec, true
+	}
+
+	if i := strings.LastIndexByte(mt, '+'); i != -1 {
+		dec, ok := c.Decoders["application/"+mt[i+1:]]
+		return dec, ok
+	}
+
+	return nil, false
+}
+
+// decodeJSON decodes a JSON value, strict decoding rejects unknown fields and trailing data
+func decodeJSON(r io.Reader, v any, strict bool) error {
+	dec := json.NewDecoder(r)
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	if strict {
+		if err := dec.Decode(&struct{}{}); err != io.EOF {
+			return errors.New("unexpected data after the JSON value")
+		}
+	}
+	return nil
+}
+
+func decodeXML(r io.Reader, v any) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// mediaType returns the media type of a Content-Type header without the parameters, like the charset or the boundary.
+// It is empty when the header is invalid.
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func encodeXML(w io.Writer, v any) error {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// encodeYAML encodes the JSON form of the value, so the fields follow the json tags like in the JSON responses
+func encodeYAML(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// a node keeps the order of the JSON keys
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle drops the flow style and the quotes of the parsed JSON, so the YAML is written in the block style
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+func encodeText(w io.Writer, v any) error {
+	_, err := io.WriteString(w, textOf(v))
+	return err
+}
+
+// encodeHTML writes the escaped text of the value in a paragraph
+func encodeHTML(w io.Writer, v any) error {
+	_, err := io.WriteString(w, "<p>"+html.EscapeString(textOf(v))+"</p>")
+	return err
+}
+
+// textOf formats a value for the text and HTML responses
+func textOf(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// encodeCSV writes a slice of structs or a struct as CSV rows with a header row.
+// The columns are named by the csv tag or the json tag of the fields, `csv:"-"` skips a field.
+func encodeCSV(w io.Writer, v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return errors.New("csv: nil value")
+		}
+		rv = rv.Elem()
+	}
+
+	var rows []reflect.Value
+	switch rv.Kind() {
+	case reflect.Struct:
+		rows = append(rows, rv)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			rows = append(rows, reflect.Indirect(rv.Index(i)))
+		}
+	default:
+		return fmt.Errorf("csv: unsupported type %s", rv.Type())
+	}
+
+	t := rv.Type()
+	if t.Kind() != reflect.Struct {
+		t = t.Elem()
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("csv: unsupported element type %s", t)
+	}
+
+	var (
+		header []string
+		fields []int
+	)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := csvName(field)
+		if name == "-" {
+			continue
+		}
+		header = append(header, name)
+		fields = append(fields, i)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(fields))
+	for _, row := range rows {
+		if !row.IsValid() {
+			continue
+		}
+		for i, index := range fields {
+			record[i] = csvValue(row.Field(index))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvName returns the column name of a field by its csv or json tag
+func csvName(field reflect.StructField) string {
+	for _, tag := range []string{"csv", "json"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func csvValue(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		if b, err := m.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -1,15 +1,27 @@
 package gale
 
 import (
+	"context"
+	"encoding/xml"
 	"errors"
 	"html/template"
+	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/coder/websocket"
 	"github.com/google/uuid"
 )
 
+const (
+	defaultBodyLimit       = 10 << 20
+	defaultMultipartMemory = 32 << 20
+)
+
 // Mode is the application mode
 type Mode string
 
@@ -24,12 +36,37 @@ type Config struct {
 	ErrorHandler func(c Ctx, err error) error
 	// NotFoundHandler handles the not found requests
 	NotFoundHandler func(c Ctx) error
+	// MethodNotAllowedHandler handles the requests where the path matches but the method does not
+	// the Allow header is already set when it is called
+	MethodNotAllowedHandler func(c Ctx) error
 	// Mode is the application mode
 	// default is development
 	Mode Mode
+	// BodyLimit is the maximum size of the request bodies in bytes, larger bodies are rejected with 413
+	// routes can override it with Route.BodyLimit
+	// by default it is 10 MB, -1 means no limit
+	BodyLimit int64
+	// MultipartMemory is the maximum memory of a multipart form in bytes, larger files are stored in temporary files
+	// by default it is 32 MB
+	MultipartMemory int64
+	// StrictJSON rejects JSON bodies with unknown fields or more than one value
+	StrictJSON bool
+	// Decoders are the request body decoders of BodyCtx.Parse and Ctx.Bind by media type, like "application/msgpack"
+	// JSON and XML decoders are added by default, types like "application/vnd.api+json" use the decoder of their suffix
+	Decoders map[string]DecoderFunc
+	// Encoders are the response encoders of Ctx.Format by media type, the encoder is chosen by the Accept header
+	// JSON, text, HTML, XML, CSV and YAML encoders are added by default, custom encoders are offered after them
+	// and can also be added later with Gale.RegisterEncoder
+	// Other formats like MessagePack or CBOR are not built in and have to be registered
+	Encoders map[string]EncoderFunc
+	// Logger is the logger of the application and the base of the request loggers
+	// by default it writes pretty console lines in development mode and JSON warnings and errors in production mode
+	Logger *slog.Logger
 
-	Views   *ViewConfig
-	Session *SessionConfig
+	Views     *ViewConfig
+	Session   *SessionConfig
+	Server    *ServerConfig
+	RequestID *RequestIDConfig
 
 	WebSocket *websocket.AcceptOptions
 	// Auth map[string]MiddlewareFunc // gale.Auth("session-default")
@@ -39,6 +76,48 @@ type ViewConfig struct {
 	Views *template.Template
 }
 
+// ServerConfig is the configuration of the underlying http.Server
+// zero values mean no limit, like in net/http
+type ServerConfig struct {
+	// ReadTimeout is the maximum duration for reading the entire request, including the body
+	ReadTimeout time.Duration
+	// ReadHeaderTimeout is the amount of time allowed to read request headers
+	ReadHeaderTimeout time.Duration
+	// WriteTimeout is the maximum duration before timing out writes of the response
+	WriteTimeout time.Duration
+	// IdleTimeout is the maximum amount of time to wait for the next request when keep-alives are enabled
+	IdleTimeout time.Duration
+	// MaxHeaderBytes is the maximum number of bytes the server will read parsing the request header
+	// by default it is http.DefaultMaxHeaderBytes
+	MaxHeaderBytes int
+	// ErrorLog is the logger for errors accepting connections and unexpected behavior from handlers
+	ErrorLog *log.Logger
+	// BaseContext returns the base context for incoming requests on the listener
+	BaseContext func(ln net.Listener) context.Context
+	// ShutdownSignals are the signals that trigger a graceful shutdown
+	// when the application is started with Serve, ServeTLS or Listener
+	ShutdownSignals []os.Signal
+	// ShutdownTimeout is the maximum duration of a shutdown triggered by a signal
+	// by default it is 10 seconds
+	ShutdownTimeout time.Duration
+}
+
+// RequestIDConfig is the configuration of the request ids returned by Ctx.ID
+type RequestIDConfig struct {
+	// Header is the request and response header of the request id
+	// by default it is "X-Request-ID"
+	Header string
+	// IgnoreHeader generates a new id even when the request has a valid id in the Header,
+	// by default the incoming id is used, like the one sent by a proxy
+	IgnoreHeader bool
+	// IgnoreTraceparent generates a new id even when the request has a valid W3C traceparent header,
+	// by default its trace id is used
+	IgnoreTraceparent bool
+	// Generator generates the ids of the requests without a trusted incoming id
+	// by default it is UUIDRequestID
+	Generator RequestIDGenerator
+}
+
 // SessionConfig is the configuration of the session
 type SessionConfig struct {
 	// Enabled is a flag to enable or disable the session
@@ -65,15 +144,59 @@ func (c *Config) check() {
 		c.NotFoundHandler = defaultNotFoundHandler
 	}
 
+	if c.MethodNotAllowedHandler == nil {
+		c.MethodNotAllowedHandler = defaultMethodNotAllowedHandler
+	}
+
 	if c.Mode == "" {
 		c.Mode = Development
 	}
 
+	if c.BodyLimit == 0 {
+		c.BodyLimit = defaultBodyLimit
+	}
+
+	if c.MultipartMemory == 0 {
+		c.MultipartMemory = defaultMultipartMemory
+	}
+
+	if c.Decoders == nil {
+		c.Decoders = make(map[string]DecoderFunc)
+	}
+	for mt, dec := range defaultDecoders(c.StrictJSON) {
+		if _, ok := c.Decoders[mt]; !ok {
+			c.Decoders[mt] = dec
+		}
+	}
+
+	if c.Encoders == nil {
+		c.Encoders = make(map[string]EncoderFunc)
+	}
+	for mt, enc := range defaultEncoders() {
+		if _, ok := c.Encoders[mt]; !ok {
+			c.Encoders[mt] = enc
+		}
+	}
+
+	if c.Logger == nil {
+		c.Logger = defaultLogger(c.Mode)
+	}
+
 	if c.Session == nil {
 		c.Session = defaultSessionConfig()
 	}
 	c.Session.check()
 
+	if c.Server == nil {
+		c.Server = defaultServerConfig()
+	}
+	c.Server.check()
+
+	if c.RequestID == nil {
+		c.RequestID = defaultRequestIDConfig()
+	}
+	c.RequestID.check()
+
 	if c.WebSocket == nil {
 		c.WebSocket = &websocket.AcceptOptions{
 			InsecureSkipVerify: c.Mode == Development,
@@ -95,12 +218,36 @@ func (s *SessionConfig) check() {
 	}
 }
 
+func (s *ServerConfig) check() {
+	if s.ShutdownTimeout == 0 {
+		s.ShutdownTimeout = time.Second * 10
+	}
+}
+
+func (r *RequestIDConfig) check() {
+	if r.Header == "" {
+		r.Header = "X-Request-ID"
+	}
+
+	if r.Generator == nil {
+		r.Generator = UUIDRequestID
+	}
+}
+
 func defaultConfig() *Config {
 	return &Config{
-		ErrorHandler:    defaultErrorHandler,
-		NotFoundHandler: defaultNotFoundHandler,
-		Mode:            Development,
-		Session:         defaultSessionConfig(),
+		ErrorHandler:            defaultErrorHandler,
+		NotFoundHandler:         defaultNotFoundHandler,
+		MethodNotAllowedHandler: defaultMethodNotAllowedHandler,
+		Mode:                    Development,
+		BodyLimit:               defaultBodyLimit,
+		MultipartMemory:         defaultMultipartMemory,
+		Decoders:                defaultDecoders(false),
+		Encoders:                defaultEncoders(),
+		Logger:                  defaultLogger(Development),
+		Session:                 defaultSessionConfig(),
+		Server:                  defaultServerConfig(),
+		RequestID:               defaultRequestIDConfig(),
 		WebSocket: &websocket.AcceptOptions{
 			InsecureSkipVerify: true,
 		},
@@ -110,6 +257,8 @@ func defaultConfig() *Config {
 type Error struct {
 	Err    string
 	Status int
+	// Fields are the invalid fields of the request, like binding errors
+	Fields []FieldError
 }
 
 func NewError(statusCode int, err string) error {
@@ -126,19 +275,57 @@ func (h *Error) Error() string {
 func defaultErrorHandler(c Ctx, err error) error {
 	code := http.StatusInternalServerError
 
+	data := errorResponse{Error: err.Error(), RequestID: c.ID()}
+
 	var e *Error
 	if errors.As(err, &e) {
 		code = e.Status
+		data.Fields = e.Fields
 	}
 
 	c.Status(code)
-	return c.Format(Map{"error": err.Error()})
+	return c.Format(data)
+}
+
+// errorResponse is the response of the default error handler, it can be sent in every format of Ctx.Format
+type errorResponse struct {
+	XMLName   xml.Name     `json:"-" xml:"error"`
+	Error     string       `json:"error" xml:"message"`
+	RequestID string       `json:"request_id" xml:"request_id"`
+	Fields    []FieldError `json:"fields,omitempty" xml:"fields>field,omitempty"`
+}
+
+// String formats the error for the text and HTML responses
+func (e errorResponse) String() string {
+	var b strings.Builder
+	b.WriteString(e.Error)
+	for _, f := range e.Fields {
+		b.WriteString("\n" + f.Field + ": " + f.Message)
+	}
+	return b.String()
 }
 
 func defaultNotFoundHandler(c Ctx) error {
 	return NewError(http.StatusNotFound, "Not found")
 }
 
+func defaultMethodNotAllowedHandler(c Ctx) error {
+	return NewError(http.StatusMethodNotAllowed, "Method not allowed")
+}
+
+func defaultServerConfig() *ServerConfig {
+	return &ServerConfig{
+		ShutdownTimeout: time.Second * 10,
+	}
+}
+
+func defaultRequestIDConfig() *RequestIDConfig {
+	return &RequestIDConfig{
+		Header:    "X-Request-ID",
+		Generator: UUIDRequestID,
+	}
+}
+
 func defaultSessionConfig() *SessionConfig {
 	return &SessionConfig{
 		Enabled:     true,
@@ -1,6 +1,7 @@
 package gale
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"encoding/xml"
@@ -8,18 +9,18 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"mime/multipart"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"reflect"
 	"slices"
 	"strconv"
 	"strings"
 
-	"github.com/fatih/color"
 	"github.com/go-spark/spark"
-	"github.com/google/uuid"
 )
 
 // Map is a map[string]any alias to make it more readable
@@ -27,6 +28,7 @@ type Map map[string]any
 
 // Ctx is the context of the request
 type Ctx interface {
+	// ID returns the request id, it is also set in the response header and the request context
 	ID() string
 
 	// Method returns the request method
@@ -40,10 +42,23 @@ type Ctx interface {
 	// Param returns a route param by name
 	Param(name string, defaultValue ...string) string
 	// ParamInt returns a route param by name as int
+	//
+	// Deprecated: use gale.Param[int](c, name), it does not return the default value with an error.
 	ParamInt(name string, defaultValue ...int) (int, error)
+	// BindParams fills the fields of a struct pointer with the route params by the param tag, like `param:"id"`
+	BindParams(v any) error
+	// Bind fills the fields of a struct pointer from the param, query, header, cookie, form and json tags.
+	// Tags can be marked as required, like `query:"page,required"`, and `default:"10"` is used for missing values.
+	// The errors are returned as a 400 *Error that lists every invalid field, then the struct is validated (see Validate).
+	Bind(v any) error
+	// Validate checks the fields of a struct by their validate tags, like `validate:"required,email"`.
+	// The invalid fields are returned as a 422 *Error.
+	Validate(v any) error
 
 	// ResponseWriter returns the http.ResponseWriter
 	ResponseWriter() http.ResponseWriter
+	// Response returns the ResponseWriter that records the response and can buffer it
+	Response() ResponseWriter
 	// Request returns the http.Request
 	Request() *http.Request
 	// Context returns the Request Context
@@ -52,13 +67,16 @@ type Ctx interface {
 	App() *Gale
 	// IP returns the client IP
 	IP() string
+	// Logger returns the request logger with the request id, route name, method and path attributes
+	Logger() *slog.Logger
 
 	// Headers
 	// Header returns a HeaderCtx to add response header and get request header
 	Header() HeaderCtx
 	// Cookie returns a CookieCtx to get and set cookies
 	Cookie() CookieCtx
-	// Session returns a SessionCtx to get and set session data (if session is enabled in the configuration)
+	// Session returns a SessionCtx to get and set session data
+	// if sessions are disabled in the configuration, its methods return ErrSessionsDisabled
 	Session() SessionCtx
 	// ContentType sets the response content type
 	ContentType(t string) Ctx
@@ -79,10 +97,12 @@ type Ctx interface {
 	SendFile(path string) error
 	// Pipe sends the output as a stream
 	Pipe(pipe func(pw *io.PipeWriter)) error
-	// Format sends the output in the format specified in the Accept header
+	// Format sends the output with the encoder of Config.Encoders that best matches the Accept header, JSON by default
 	Format(data any) error
 	// Redirect redirects the request to the specified URL
 	Redirect(to string) error
+	// RedirectRoute redirects the request to a named route
+	RedirectRoute(name string, params Map) error
 
 	Spark(component spark.Component) error
 
@@ -106,13 +126,32 @@ type Ctx interface {
 
 	// Break stops the request chain execution
 	Break() Ctx
+	// Next runs the rest of the middleware chain and the handler, and returns their error.
+	// Middlewares that do not call Next are followed by the next handler automatically.
+	Next() error
 	// Route returns the current route
 	Route() Route
+	// Accepts returns the best offered media type for the Accept header, by quality values and wildcards.
+	// Without an Accept header it returns the first offer, if none of them is acceptable it returns an empty string.
+	Accepts(offers ...string) string
+	// AcceptsLanguages returns the best offered language for the Accept-Language header, like Accepts
+	AcceptsLanguages(offers ...string) string
+	// AcceptsEncodings returns the best offered encoding for the Accept-Encoding header, like Accepts
+	AcceptsEncodings(offers ...string) string
+	// AcceptsCharsets returns the best offered charset for the Accept-Charset header, like Accepts
+	AcceptsCharsets(offers ...string) string
+	// RouteURL builds the path of a named route (see CompleteRouter.URL)
+	RouteURL(name string, params Map) (string, error)
 
 	// Framework methods
 
 	canContinue() bool
 	isWritten() bool
+	writeHeaders()
+	resetResponse()
+	onFinish(fn func())
+	finished()
+	paramCache() map[paramCacheKey]cachedParam
 }
 
 // HeaderCtx is the context of the request headers
@@ -127,15 +166,20 @@ type HeaderCtx interface {
 
 // BodyCtx is the context of the request body
 type BodyCtx interface {
-	// Parse the request body to any by the Content-Type header
+	// Parse the request body to any by the Content-Type header, then validate it with Ctx.Validate
+	// JSON, XML and forms are supported by default, other media types can be added with Config.Decoders
 	Parse(v any) error
 	// ParseJSON parses the request body as JSON
 	ParseJSON(v any) error
 	// ParseXML parses the request body as XML
 	ParseXML(v any) error
-	// ParseForm parses the request body as form
+	// ParseForm parses an urlencoded or multipart form into a struct pointer by the form tags, like `form:"name"`
+	// nested structs use prefixed keys like "address.city", slices get the repeated keys
 	ParseForm(v any) error
+	// Bytes reads the whole request body, it can be called multiple times and the body can be parsed after it
+	Bytes() ([]byte, error)
 	// File returns a file from the request
+	// maxSize is the memory limit of the multipart form in megabytes, by default it is Config.MultipartMemory
 	File(name string, maxSize ...int) (multipart.File, *multipart.FileHeader, error)
 }
 
@@ -176,8 +220,12 @@ type ctx struct {
 	b           *Gale
 	route       Route
 	routeParams map[string]string
+	// params are the route params converted by gale.Param and the built-in validators
+	params map[paramCacheKey]cachedParam
+	// body is the request body read by BodyCtx.Bytes
+	body []byte
 
-	w http.ResponseWriter
+	w *responseWriter
 	r *http.Request
 
 	statusCode int
@@ -185,7 +233,14 @@ type ctx struct {
 
 	store map[string]any
 
+	chain []HandlerFunc
+	index int
+
+	logger *slog.Logger
+
 	written bool
+	// finish are the functions that run after the response is sent
+	finish []func()
 
 	breakChain bool
 }
@@ -203,25 +258,53 @@ func newTestCtx(g *Gale, w http.ResponseWriter, r *http.Request, routePath ...st
 	router := g.Router()
 	route := router.Add(r.Method, path, nil, nil)
 
-	ok, props := route.comparePath(router, requestPath)
-	if !ok {
+	tree := newTree()
+	tree.insert(route)
+
+	matched, props := matchTree(tree, requestPath)
+	if matched == nil {
 		log.Fatal("route path does not match request path")
 	}
 
-	return newCtx(g, route, w, r, props)
+	return newCtx(g, route, w, r, props, nil)
 }
 
-func newCtx(b *Gale, route Route, w http.ResponseWriter, r *http.Request, routeParams map[string]string) Ctx {
+// newCtx creates the context of a request, typed are the params converted by the built-in validators while matching
+func newCtx(b *Gale, route Route, w http.ResponseWriter, r *http.Request, routeParams map[string]string, typed map[string]any) Ctx {
+	var chain []HandlerFunc
+	if route != nil {
+		for _, m := range route.Middlewares() {
+			chain = append(chain, HandlerFunc(m))
+		}
+
+		if route.Handler() != nil {
+			chain = append(chain, route.Handler())
+		}
+	}
+
+	// the server attaches the id before matching, only test contexts get a new one here
+	r, id := b.withRequestID(w, r)
+
+	var params map[paramCacheKey]cachedParam
+	if len(typed) > 0 {
+		params = make(map[paramCacheKey]cachedParam, len(typed))
+		for name, value := range typed {
+			params[paramCacheKey{name: name, typ: reflect.TypeOf(value)}] = cachedParam{raw: routeParams[name], value: value}
+		}
+	}
+
 	return &ctx{
-		id:          uuid.New().String(),
+		id:          id,
 		b:           b,
 		route:       route,
 		routeParams: routeParams,
-		w:           w,
+		params:      params,
+		w:           newResponseWriter(w),
 		r:           r,
 		statusCode:  200,
 		headers:     make(map[string][]string),
 		store:       make(map[string]any),
+		chain:       chain,
 		written:     false,
 		breakChain:  false,
 	}
@@ -236,10 +319,26 @@ func (c *ctx) Break() Ctx {
 	return c
 }
 
+func (c *ctx) Next() error {
+	for c.index < len(c.chain) && c.canContinue() {
+		fn := c.chain[c.index]
+		c.index++
+
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (c *ctx) Route() Route {
 	return c.route
 }
 
+func (c *ctx) RouteURL(name string, params Map) (string, error) {
+	return c.b.URL(name, params)
+}
+
 func (c *ctx) canContinue() bool {
 	return !c.breakChain
 }
@@ -248,6 +347,32 @@ func (c *ctx) isWritten() bool {
 	return c.written
 }
 
+// onFinish registers a function that runs after the response is sent, when the status and size are final
+func (c *ctx) onFinish(fn func()) {
+	c.finish = append(c.finish, fn)
+}
+
+// finished runs the functions registered with onFinish, the server calls it after committing the response
+func (c *ctx) finished() {
+	for _, fn := range c.finish {
+		fn()
+	}
+}
+
+// resetResponse discards a buffered response that is not committed yet with the headers set by the handlers,
+// so an error response can replace it
+func (c *ctx) resetResponse() {
+	if !c.w.Buffered() || c.w.committed {
+		return
+	}
+
+	c.w.reset()
+	// the headers are already copied to the response writer
+	c.headers = make(map[string][]string)
+	c.statusCode = http.StatusOK
+	c.written = false
+}
+
 func (c *ctx) App() *Gale {
 	return c.b
 }
@@ -266,6 +391,23 @@ func (c *ctx) IP() string {
 	return c.ipHelper(c.r.RemoteAddr)
 }
 
+func (c *ctx) Logger() *slog.Logger {
+	if c.logger == nil {
+		var name string
+		if c.route != nil {
+			name = c.route.GetName()
+		}
+
+		c.logger = c.b.config.Logger.With(
+			slog.String("request_id", c.ID()),
+			slog.String("route", name),
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+		)
+	}
+	return c.logger
+}
+
 func (c *ctx) ipHelper(s string) string {
 	ip := strings.TrimSpace(strings.Split(s, ",")[0])
 	host, _, _ := net.SplitHostPort(ip)
@@ -276,6 +418,10 @@ func (c *ctx) ResponseWriter() http.ResponseWriter {
 	return c.w
 }
 
+func (c *ctx) Response() ResponseWriter {
+	return c.w
+}
+
 func (c *ctx) Request() *http.Request {
 	return c.r
 }
@@ -323,6 +469,64 @@ func (c *ctx) ParamInt(name string, defaultValue ...int) (int, error) {
 	return strconv.Atoi(param)
 }
 
+func (c *ctx) BindParams(v any) error {
+	b := &binder{sources: []bindSource{paramSource(c)}}
+
+	errs, err := b.bind(v)
+	if err != nil {
+		return err
+	}
+	return bindError(errs)
+}
+
+func (c *ctx) Bind(v any) error {
+	var sources []bindSource
+
+	if c.r.ContentLength != 0 && c.r.Body != nil && c.r.Body != http.NoBody {
+		body := &bodyCtx{c: c}
+
+		switch mt := mediaType(c.r.Header.Get("Content-Type")); mt {
+		case ContentTypeForm, ContentTypeMultipart:
+			form, err := body.form()
+			if err != nil {
+				return err
+			}
+			sources = append(sources, form)
+		default:
+			err := decodeBody(v, func(v any) error {
+				return body.decode(mt, v)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// json fields are decoded with the body, the source only checks the required and default tags
+	sources = append([]bindSource{paramSource(c)}, append(requestSources(c.r), append(sources, bindSource{tag: "json"})...)...)
+
+	errs, err := (&binder{sources: sources}).bind(v)
+	if err != nil {
+		return err
+	}
+
+	if err := bindError(errs); err != nil {
+		return err
+	}
+	return c.Validate(v)
+}
+
+func (c *ctx) Validate(v any) error {
+	return c.b.validator.validate(v)
+}
+
+func (c *ctx) paramCache() map[paramCacheKey]cachedParam {
+	if c.params == nil {
+		c.params = make(map[paramCacheKey]cachedParam)
+	}
+	return c.params
+}
+
 func (c *ctx) Header() HeaderCtx {
 	return &headerCtx{
 		c: c,
@@ -389,42 +593,43 @@ func (c *ctx) SendFile(path string) error {
 func (c *ctx) Pipe(pipe func(pw *io.PipeWriter)) error {
 	pr, pw := io.Pipe()
 
+	// the stream is tracked so a graceful shutdown waits for it
+	c.b.inflight.Add(1)
 	go func(pw *io.PipeWriter) {
+		defer c.b.inflight.Done()
 		defer pw.Close()
 		pipe(pw)
 	}(pw)
 
 	c.writeHeaders()
 	_, err := io.Copy(c.w, pr)
+
+	// unblock the writer if the client went away
+	_ = pr.CloseWithError(io.ErrClosedPipe)
 	return err
 }
 
 func (c *ctx) Format(data any) error {
-	var allowedFormats = []string{ContentTypeJSON, ContentTypeText, ContentTypeHTML, ContentTypeXML}
-	format := c.getHeaderAllowedFormat(allowedFormats, ContentTypeJSON)
-
-	var d string
-	switch v := data.(type) {
-	case string:
-		d = v
-	case []byte:
-		d = string(v)
-	default:
-		d = fmt.Sprintf("%v", v)
+	c.Header().Add("Vary", "Accept")
+
+	// unacceptable requests get JSON instead of a 406 response
+	encoders, types := c.b.encoders.get()
+	mt := c.Accepts(types...)
+	if mt == "" {
+		mt = ContentTypeJSON
 	}
 
-	switch format {
-	case ContentTypeJSON:
+	enc, ok := encoders[mt]
+	if !ok {
 		return c.JSON(data)
-	case ContentTypeText:
-		return c.SendString(d)
-	case ContentTypeHTML:
-		return c.ContentType(ContentTypeHTML).SendString("<p>" + d + "</p>")
-	case ContentTypeXML:
-		return c.XML(data)
 	}
 
-	return c.SendString(d)
+	var buf bytes.Buffer
+	if err := enc(&buf, data); err != nil {
+		return err
+	}
+
+	return c.ContentType(mt).Send(buf.Bytes())
 }
 
 func (c *ctx) Redirect(to string) error {
@@ -436,6 +641,14 @@ func (c *ctx) Redirect(to string) error {
 	return nil
 }
 
+func (c *ctx) RedirectRoute(name string, params Map) error {
+	to, err := c.RouteURL(name, params)
+	if err != nil {
+		return err
+	}
+	return c.Redirect(to)
+}
+
 func (c *ctx) Spark(component spark.Component) error {
 	return component.Response(c.w, c.r)
 }
@@ -464,16 +677,14 @@ func (c *ctx) Cookie() CookieCtx {
 
 func (c *ctx) Session() SessionCtx {
 	if !c.b.config.Session.Enabled {
-		color.Red("🛑 Sessions are disabled, please enable it in the config or do not use the session context")
-		os.Exit(1)
+		return &sessionCtx{c: c, err: ErrSessionsDisabled}
 	}
 	return newSessionCtx(c)
 }
 
 func (c *ctx) writeHeaders() {
 	if c.written {
-		cl := color.New(color.FgRed, color.Bold)
-		cl.Println("⚠️ Headers already written, cannot write headers again.")
+		c.Logger().Warn("headers already written, cannot write headers again")
 		return
 	}
 
@@ -487,27 +698,16 @@ func (c *ctx) writeHeaders() {
 	c.written = true
 }
 
-func (c *ctx) getHeaderAllowedFormat(allowed []string, defaultValue string) string {
-	acceptHeader := c.r.Header.Get("Accept")
-	if acceptHeader == "" {
-		return defaultValue
-	}
-
-	wants := strings.Split(acceptHeader, ",")
-	for _, format := range wants {
-		if slices.Contains(allowed, format) {
-			return format
-		}
-	}
-
-	return defaultValue
-}
-
 // Implementing the SessionCtx
 
+// ErrSessionsDisabled is returned by the session methods when the sessions are disabled in the config
+var ErrSessionsDisabled = errors.New("sessions are disabled, please enable it in the config or do not use the session context")
+
 type sessionCtx struct {
 	c  *ctx
 	id string
+	// err is returned by every method that uses the store
+	err error
 }
 
 func newSessionCtx(c *ctx) SessionCtx {
@@ -518,8 +718,9 @@ func newSessionCtx(c *ctx) SessionCtx {
 
 func (s *sessionCtx) From(id string) SessionCtx {
 	return &sessionCtx{
-		c:  s.c,
-		id: id,
+		c:   s.c,
+		id:  id,
+		err: s.err,
 	}
 }
 
@@ -533,6 +734,10 @@ func (s *sessionCtx) SetID(id string) SessionCtx {
 }
 
 func (s *sessionCtx) Get(key string) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
 	conf := s.c.b.config.Session
 
 	var (
@@ -564,6 +769,10 @@ func (s *sessionCtx) Get(key string) ([]byte, error) {
 }
 
 func (s *sessionCtx) Set(key string, value []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+
 	conf := s.c.b.config.Session
 
 	var (
@@ -598,6 +807,10 @@ func (s *sessionCtx) Set(key string, value []byte) error {
 }
 
 func (s *sessionCtx) Delete(key string) error {
+	if s.err != nil {
+		return s.err
+	}
+
 	conf := s.c.b.config.Session
 
 	var (
@@ -632,6 +845,10 @@ func (s *sessionCtx) Delete(key string) error {
 }
 
 func (s *sessionCtx) Destroy() error {
+	if s.err != nil {
+		return s.err
+	}
+
 	conf := s.c.b.config.Session
 
 	var (
@@ -698,44 +915,111 @@ func (h *headerCtx) GetAll() map[string][]string {
 
 // Implementing the BodyCtx
 
+// bodyError converts the errors of reading the body, a body over the limit is a 413 error, others are 400 errors
+func bodyError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return NewError(http.StatusRequestEntityTooLarge, "Request Entity Too Large")
+	}
+	return NewError(http.StatusBadRequest, "invalid body: "+err.Error())
+}
+
 type bodyCtx struct {
 	c *ctx
 }
 
+// reader returns the request body, it is rewound when the body was read by Bytes
+func (b *bodyCtx) reader() io.Reader {
+	if b.c.body != nil {
+		b.c.r.Body = io.NopCloser(bytes.NewReader(b.c.body))
+	}
+	return b.c.r.Body
+}
+
+func (b *bodyCtx) Bytes() ([]byte, error) {
+	if b.c.body == nil {
+		body, err := io.ReadAll(b.c.r.Body)
+		if err != nil {
+			return nil, bodyError(err)
+		}
+		b.c.body = body
+	}
+
+	b.reader()
+	return b.c.body, nil
+}
+
 func (b *bodyCtx) ParseJSON(v any) error {
-	return json.NewDecoder(b.c.r.Body).Decode(v)
+	if err := decodeJSON(b.reader(), v, b.c.b.config.StrictJSON); err != nil {
+		return bodyError(err)
+	}
+	return nil
 }
 
 func (b *bodyCtx) ParseForm(v any) error {
-	return NewError(http.StatusNotImplemented, "Not implemented")
+	form, err := b.form()
+	if err != nil {
+		return err
+	}
+
+	errs, err := (&binder{sources: []bindSource{form}}).bind(v)
+	if err != nil {
+		return err
+	}
+	return bindError(errs)
+}
+
+func (b *bodyCtx) form() (bindSource, error) {
+	b.reader()
+	return formSource(b.c.r, b.c.b.config.MultipartMemory)
 }
 
 func (b *bodyCtx) ParseXML(v any) error {
-	return xml.NewDecoder(b.c.r.Body).Decode(v)
+	if err := decodeXML(b.reader(), v); err != nil {
+		return bodyError(err)
+	}
+	return nil
+}
+
+// decode decodes the body with the decoder of the media type
+func (b *bodyCtx) decode(mt string, v any) error {
+	dec, ok := b.c.b.config.decoder(mt)
+	if !ok {
+		return NewError(http.StatusUnsupportedMediaType, "Unsupported Media Type")
+	}
+
+	if err := dec(b.reader(), v); err != nil {
+		return bodyError(err)
+	}
+	return nil
 }
 
 func (b *bodyCtx) Parse(v any) error {
-	switch b.c.r.Header.Get("Content-Type") {
-	case ContentTypeJSON:
-		return b.ParseJSON(v)
-	case ContentTypeXML:
-		return b.ParseXML(v)
-	case ContentTypeForm:
-		return b.ParseForm(v)
+	var err error
+
+	switch mt := mediaType(b.c.r.Header.Get("Content-Type")); mt {
+	case ContentTypeForm, ContentTypeMultipart:
+		err = b.ParseForm(v)
+	default:
+		err = b.decode(mt, v)
 	}
 
-	return NewError(http.StatusUnprocessableEntity, "Unprocessable Entity")
+	if err != nil {
+		return err
+	}
+	return b.c.Validate(v)
 }
 
 func (b *bodyCtx) File(name string, maxSize ...int) (multipart.File, *multipart.FileHeader, error) {
-	var size = 10
+	size := b.c.b.config.MultipartMemory
 	if len(maxSize) > 0 {
-		size = maxSize[0]
+		size = int64(maxSize[0]) << 20
 	}
 
-	err := b.c.r.ParseMultipartForm(int64(size) << 20)
+	b.reader()
+	err := b.c.r.ParseMultipartForm(size)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, bodyError(err)
 	}
 
 	return b.c.r.FormFile(name)
@@ -2,11 +2,13 @@ package gale
 
 import (
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"slices"
+	"sync"
 	"time"
-
-	"github.com/fatih/color"
 )
 
 // Version is the current version of Gale
@@ -20,6 +22,19 @@ type Gale struct {
 	server    *server
 	publicDir string
 	hooks     map[GaleHook][]func(c Ctx) error
+	lifecycle lifecycleHooks
+	validator *validator
+	encoders  *encoders
+	// accessLoggers are the access logs of the application, see Gale.AccessLog
+	accessLoggers []*accessLogger
+	// errs are the registration errors of the application, router errors are stored by the router
+	errs []error
+
+	mu          sync.Mutex
+	httpServers []*http.Server
+	wsServers   map[WSServer]struct{}
+	inflight    sync.WaitGroup
+	signalOnce  sync.Once
 }
 
 // New creates a new Gale application with the given configuration
@@ -38,8 +53,15 @@ func New(conf ...*Config) *Gale {
 		CompleteRouter: newRouter(),
 		publicDir:      "",
 		hooks:          make(map[GaleHook][]func(c Ctx) error),
+		wsServers:      make(map[WSServer]struct{}),
+		validator:      newValidator(),
+		encoders:       newEncoders(c.Encoders),
 	}
 	g.server = &server{g}
+	g.CompleteRouter.(*router).onRoute = g.runRouteHooks
+	g.CompleteRouter.(*router).onWarn = func(msg string, args ...any) {
+		g.config.Logger.Warn(msg, args...)
+	}
 
 	registerDefaultRouteValidators(g)
 	return g
@@ -64,12 +86,34 @@ func (g *Gale) PublicDir(path string) error {
 // Note: Hooks are methods that are executed before or after a request is processed
 func (g *Gale) Hook(hook GaleHook, fns ...func(c Ctx) error) {
 	if len(fns) == 0 {
-		color.Red("No functions provided for hook")
-		os.Exit(1)
+		g.errs = append(g.errs, fmt.Errorf("no functions provided for hook %d", hook))
+		return
 	}
 	g.hooks[hook] = append(g.hooks[hook], fns...)
 }
 
+// Validate returns the errors of registering the routes, validators and hooks, including the mounted applications.
+// Serve, ServeTLS and Listener call it before they start listening,
+// applications served with Handler should call it after the routes are registered.
+func (g *Gale) Validate() error {
+	errs := append(slices.Clone(g.errs), g.registrationErrors()...)
+	for _, app := range g.mountedApps() {
+		if err := app.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MountApp mounts a sub application under the prefix.
+// The sub application handles the requests with its own routes, hooks and error handlers.
+func (g *Gale) MountApp(prefix string, sub *Gale) Route {
+	route := g.mount(prefix, http.HandlerFunc(sub.server.serve), sub, nil)
+	// the sub application limits the bodies by its own config and routes
+	route.BodyLimit(-1)
+	return route
+}
+
 // Use registers an extension for the Gale application
 func (g *Gale) Use(fn UseExtension) {
 	fn.Register(g)
@@ -89,16 +133,89 @@ func (g *Gale) NewTestContext(w http.ResponseWriter, r *http.Request, route ...s
 	return newTestCtx(g, w, r, route...)
 }
 
+// Handler returns the Gale application as a http.Handler
+// it can be used with a custom http.Server or wrapped by other middlewares
+func (g *Gale) Handler() http.Handler {
+	return g.server
+}
+
 // Serve starts the Gale server on the given address
 func (g *Gale) Serve(listenAddr string) error {
-	displayServeInfo(listenAddr, g.config.Mode)
-	g.start = time.Now()
-	return http.ListenAndServe(listenAddr, g.server)
+	ln, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return err
+	}
+
+	return g.serve(listenAddr, ln, func(srv *http.Server) error {
+		return srv.Serve(ln)
+	})
 }
 
 // ServeTLS starts the Gale server on the given address with TLS
 func (g *Gale) ServeTLS(listenAddr, certFile, keyFile string) error {
-	displayServeInfo(listenAddr, g.config.Mode)
+	ln, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return err
+	}
+
+	return g.serve(listenAddr, ln, func(srv *http.Server) error {
+		return srv.ServeTLS(ln, certFile, keyFile)
+	})
+}
+
+// Listener starts the Gale server on the given listener, like a Unix domain socket
+func (g *Gale) Listener(ln net.Listener) error {
+	return g.serve(ln.Addr().String(), ln, func(srv *http.Server) error {
+		return srv.Serve(ln)
+	})
+}
+
+// serve runs the startup hooks and serves the listener
+func (g *Gale) serve(addr string, ln net.Listener, serve func(srv *http.Server) error) error {
+	if err := g.Validate(); err != nil {
+		_ = ln.Close()
+		return err
+	}
+
+	srv := g.newHTTPServer(addr)
+
+	if err := g.runStartupHooks(addr); err != nil {
+		_ = ln.Close()
+		return err
+	}
+
+	displayServeInfo(addr, g.config.Mode)
 	g.start = time.Now()
-	return http.ListenAndServeTLS(listenAddr, certFile, keyFile, g.server)
+	return serverClosed(serve(srv))
+}
+
+func (g *Gale) newHTTPServer(addr string) *http.Server {
+	conf := g.config.Server
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           g.server,
+		ReadTimeout:       conf.ReadTimeout,
+		ReadHeaderTimeout: conf.ReadHeaderTimeout,
+		WriteTimeout:      conf.WriteTimeout,
+		IdleTimeout:       conf.IdleTimeout,
+		MaxHeaderBytes:    conf.MaxHeaderBytes,
+		ErrorLog:          conf.ErrorLog,
+		BaseContext:       conf.BaseContext,
+	}
+
+	g.mu.Lock()
+	g.httpServers = append(g.httpServers, srv)
+	g.mu.Unlock()
+
+	g.handleShutdownSignals()
+	return srv
+}
+
+// serverClosed hides the error returned by a server after a graceful shutdown
+func serverClosed(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
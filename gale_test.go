@@ -1,12 +1,17 @@
 package gale
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestServer(t *testing.T) {
@@ -119,3 +124,149 @@ func TestServer(t *testing.T) {
 	app.Dump()
 	log.Fatal(app.Serve(":3001"))
 }
+
+func TestListener(t *testing.T) {
+	app := New()
+	app.Get("/", func(c Ctx) error {
+		return c.SendString("Hello")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer ln.Close()
+
+	go func() {
+		_ = app.Listener(ln)
+	}()
+
+	res, err := http.Get("http://" + ln.Addr().String())
+	assert.Nil(t, err)
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	assert.Equal(t, "Hello", string(body))
+
+	w := httptest.NewRecorder()
+	app.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "Hello", w.Body.String())
+}
+
+func TestShutdown(t *testing.T) {
+	app := New()
+
+	started := make(chan struct{})
+	app.Get("/slow", func(c Ctx) error {
+		close(started)
+		time.Sleep(time.Millisecond * 200)
+		return c.SendString("done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	served := make(chan error)
+	go func() {
+		served <- app.Listener(ln)
+	}()
+
+	result := make(chan string)
+	go func() {
+		res, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		result <- string(body)
+	}()
+
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.Nil(t, app.Shutdown(ctx))
+	assert.Equal(t, "done", <-result)
+	assert.Nil(t, <-served)
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	app := New()
+
+	var events []string
+	app.OnStartup(func(addr string) error {
+		events = append(events, "startup")
+		return nil
+	})
+	app.OnShutdown(func(ctx context.Context) error {
+		events = append(events, "shutdown 1")
+		return nil
+	}, func(ctx context.Context) error {
+		events = append(events, "shutdown 2")
+		return nil
+	})
+	app.OnRouteRegistered(func(route Route) {
+		events = append(events, "route "+route.Path())
+	})
+	app.OnError(func(c Ctx, err error) {
+		events = append(events, "error "+err.Error())
+	})
+
+	app.Get("/fail", func(c Ctx) error {
+		return NewError(http.StatusTeapot, "failed")
+	})
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		sub := New()
+		sub.OnStartup(func(addr string) error {
+			events = append(events, "startup "+name)
+			return nil
+		})
+		sub.OnShutdown(func(ctx context.Context) error {
+			events = append(events, "shutdown "+name)
+			return nil
+		})
+		app.MountApp("/"+name, sub)
+	}
+
+	w := httptest.NewRecorder()
+	app.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	assert.Equal(t, http.StatusTeapot, w.Code)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	served := make(chan error)
+	go func() {
+		served <- app.Listener(ln)
+	}()
+
+	// wait until the server accepts connections
+	res, err := http.Get("http://" + ln.Addr().String() + "/fail")
+	assert.Nil(t, err)
+	res.Body.Close()
+
+	assert.Nil(t, app.Shutdown(context.Background()))
+	assert.Nil(t, <-served)
+
+	assert.Equal(t, []string{
+		"route /fail",
+		"route /a/{path...}?",
+		"route /b/{path...}?",
+		"route /c/{path...}?",
+		"route /d/{path...}?",
+		"error failed",
+		"startup",
+		"startup a",
+		"startup b",
+		"startup c",
+		"startup d",
+		"error failed",
+		"shutdown d",
+		"shutdown c",
+		"shutdown b",
+		"shutdown a",
+		"shutdown 2",
+		"shutdown 1",
+	}, events)
+}
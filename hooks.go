@@ -0,0 +1,90 @@
+package gale
+
+import (
+	"context"
+	"errors"
+)
+
+// lifecycleHooks are the hooks that run on application events instead of requests
+type lifecycleHooks struct {
+	startup  []StartupHookFunc
+	shutdown []ShutdownHookFunc
+	route    []RouteHookFunc
+	error    []ErrorHookFunc
+}
+
+// OnStartup registers functions that run in registration order when the server starts listening,
+// before the first request is served. If a function returns an error, the server is not started.
+// The hooks of the apps added with MountApp run after the ones of the parent app, in mount order.
+func (g *Gale) OnStartup(fns ...StartupHookFunc) {
+	g.lifecycle.startup = append(g.lifecycle.startup, fns...)
+}
+
+// OnShutdown registers functions that run when Shutdown is called, before the server stops.
+// They run in reverse registration order, so extensions registered later are stopped first.
+// The hooks of the apps added with MountApp run before the ones of the parent app, in reverse mount order.
+func (g *Gale) OnShutdown(fns ...ShutdownHookFunc) {
+	g.lifecycle.shutdown = append(g.lifecycle.shutdown, fns...)
+}
+
+// OnRouteRegistered registers functions that run in registration order every time a route is added.
+// Note: route names are set after the registration, read them later from the Route.
+// MountApp is reported as a single "/prefix/{path...}?" route, the routes of the sub application
+// run the hooks of the sub application only.
+func (g *Gale) OnRouteRegistered(fns ...RouteHookFunc) {
+	g.lifecycle.route = append(g.lifecycle.route, fns...)
+}
+
+// OnError registers functions that run in registration order with every error
+// before it is passed to the Config.ErrorHandler.
+func (g *Gale) OnError(fns ...ErrorHookFunc) {
+	g.lifecycle.error = append(g.lifecycle.error, fns...)
+}
+
+// runStartupHooks runs the startup hooks, then the ones of the mounted applications in mount order
+func (g *Gale) runStartupHooks(addr string) error {
+	for _, fn := range g.lifecycle.startup {
+		if err := fn(addr); err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range g.mountedApps() {
+		if err := sub.runStartupHooks(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runShutdownHooks runs the hooks in the reverse order of runStartupHooks:
+// the mounted applications in reverse mount order, then the hooks of the application in reverse registration order
+func (g *Gale) runShutdownHooks(ctx context.Context) error {
+	var errs []error
+
+	subs := g.mountedApps()
+	for i := len(subs) - 1; i >= 0; i-- {
+		if err := subs[i].runShutdownHooks(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for i := len(g.lifecycle.shutdown) - 1; i >= 0; i-- {
+		if err := g.lifecycle.shutdown[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (g *Gale) runRouteHooks(route Route) {
+	for _, fn := range g.lifecycle.route {
+		fn(route)
+	}
+}
+
+func (g *Gale) runErrorHooks(c Ctx, err error) {
+	for _, fn := range g.lifecycle.error {
+		fn(c, err)
+	}
+}
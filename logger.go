@@ -0,0 +1,176 @@
+package gale
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buger/goterm"
+	"github.com/fatih/color"
+)
+
+// ConsoleHandler is a slog.Handler that writes human friendly, colored lines to the console.
+// Request records (message "request" with method, path and duration attributes) are written as a dotted line.
+type ConsoleHandler struct {
+	w     io.Writer
+	level slog.Leveler
+	attrs []slog.Attr
+	group string
+	mu    *sync.Mutex
+}
+
+// NewConsoleHandler creates a new ConsoleHandler that writes to w
+func NewConsoleHandler(w io.Writer, opts *slog.HandlerOptions) *ConsoleHandler {
+	var level slog.Leveler = slog.LevelInfo
+	if opts != nil && opts.Level != nil {
+		level = opts.Level
+	}
+
+	return &ConsoleHandler{
+		w:     w,
+		level: level,
+		mu:    &sync.Mutex{},
+	}
+}
+
+func (h *ConsoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *ConsoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append(append([]slog.Attr{}, h.attrs...), h.prefixed(attrs)...)
+	return &h2
+}
+
+func (h *ConsoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := *h
+	h2.group = h.group + name + "."
+	return &h2
+}
+
+func (h *ConsoleHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := append([]slog.Attr{}, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, h.prefixed([]slog.Attr{a})...)
+		return true
+	})
+
+	var line string
+	if method, path, duration, ok := requestAttrs(attrs); ok && r.Message == "request" {
+		line = h.requestLine(method, path, duration)
+	} else {
+		line = h.messageLine(r, attrs)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := io.WriteString(h.w, line)
+	return err
+}
+
+func (h *ConsoleHandler) prefixed(attrs []slog.Attr) []slog.Attr {
+	if h.group == "" {
+		return attrs
+	}
+
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		out[i] = slog.Attr{Key: h.group + a.Key, Value: a.Value}
+	}
+	return out
+}
+
+// requestLine formats a request as " GET /path ........ 1.2ms "
+func (h *ConsoleHandler) requestLine(method, path string, duration time.Duration) string {
+	method, l := methodSpaces(method)
+	colorMethod := colorMethodName(method)
+	mDots := strings.Repeat(".", l)
+
+	colorMethod = mDots + colorMethod
+
+	timeString := duration.String()
+	colorTime := color.New(color.FgHiBlack).Sprint(timeString)
+
+	width := goterm.Width()
+	width = width - len(mDots+method) - len(path) - len(timeString) - 5 /* 5 spaces */
+
+	if width < 5 {
+		width = 5
+	}
+
+	dots := strings.Repeat(".", width)
+
+	return fmt.Sprintf(" %s %s %s %s \n", colorMethod, path, dots, colorTime)
+}
+
+func (h *ConsoleHandler) messageLine(r slog.Record, attrs []slog.Attr) string {
+	var b strings.Builder
+
+	b.WriteString(" ")
+	b.WriteString(colorLevel(r.Level))
+	b.WriteString(" ")
+	b.WriteString(r.Message)
+
+	for _, a := range attrs {
+		b.WriteString(" ")
+		b.WriteString(color.New(color.FgHiBlack).Sprint(a.Key + "="))
+		b.WriteString(a.Value.String())
+	}
+
+	b.WriteString("\n")
+	return b.String()
+}
+
+func colorLevel(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return color.New(color.FgHiRed, color.Bold).Sprint(level.String())
+	case level >= slog.LevelWarn:
+		return color.New(color.FgHiYellow).Sprint(level.String())
+	case level >= slog.LevelInfo:
+		return color.New(color.FgHiBlue).Sprint(level.String())
+	default:
+		return color.New(color.FgHiBlack).Sprint(level.String())
+	}
+}
+
+// requestAttrs returns the request attributes of a record logged by the server
+func requestAttrs(attrs []slog.Attr) (method, path string, duration time.Duration, ok bool) {
+	var found int
+	for _, a := range attrs {
+		switch a.Key {
+		case "method":
+			method = a.Value.String()
+			found++
+		case "path":
+			path = a.Value.String()
+			found++
+		case "duration":
+			if a.Value.Kind() == slog.KindDuration {
+				duration = a.Value.Duration()
+				found++
+			}
+		}
+	}
+	return method, path, duration, found == 3
+}
+
+func defaultLogger(mode Mode) *slog.Logger {
+	if mode == Development {
+		return slog.New(NewConsoleHandler(color.Output, nil))
+	}
+
+	// request lines are only logged in development mode by default
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+}
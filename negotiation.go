@@ -0,0 +1,160 @@
+package gale
+
+import (
+	"strconv"
+	"strings"
+)
+
+// acceptRange is an item of an Accept header with its quality value, like "text/*;q=0.8"
+type acceptRange struct {
+	value string
+	q     float64
+}
+
+// parseAccept parses an Accept, Accept-Language, Accept-Encoding or Accept-Charset header.
+// The values are lowercased and the parameters other than q are dropped.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+
+	for _, item := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(item, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, v, _ := strings.Cut(param, "=")
+			if strings.TrimSpace(strings.ToLower(key)) != "q" {
+				continue
+			}
+
+			if n, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && n >= 0 && n <= 1 {
+				q = n
+			}
+		}
+
+		ranges = append(ranges, acceptRange{value: value, q: q})
+	}
+
+	return ranges
+}
+
+// negotiate returns the offer with the highest quality in the header.
+// match returns the specificity of a range for an offer, or -1 when the range does not match the offer,
+// the quality of the most specific matching range is used for every offer.
+// Without a header the first offer is returned, when no offer is acceptable it returns an empty string.
+func negotiate(header string, offers []string, match func(rng, offer string) int) string {
+	if len(offers) == 0 {
+		return ""
+	}
+
+	if strings.TrimSpace(header) == "" {
+		return offers[0]
+	}
+
+	ranges := parseAccept(header)
+
+	var (
+		best            string
+		bestQ           float64
+		bestSpecificity = -1
+	)
+
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, rng := range ranges {
+			if s := match(rng.value, strings.ToLower(offer)); s > specificity {
+				q, specificity = rng.q, s
+			}
+		}
+
+		if specificity == -1 || q == 0 {
+			continue
+		}
+
+		// ties are resolved by the specificity of the matched range, then by the order of the offers
+		if q > bestQ || (q == bestQ && specificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = offer, q, specificity
+		}
+	}
+
+	return best
+}
+
+// matchMediaType matches media ranges like "*/*", "text/*" and "text/html"
+func matchMediaType(rng, offer string) int {
+	offer, _, _ = strings.Cut(offer, ";")
+	offerType, offerSubtype, _ := strings.Cut(strings.TrimSpace(offer), "/")
+	rngType, rngSubtype, _ := strings.Cut(rng, "/")
+
+	switch {
+	case rngType == "*" && rngSubtype == "*":
+		return 0
+	case rngType == offerType && rngSubtype == "*":
+		return 1
+	case rngType == offerType && rngSubtype == offerSubtype:
+		return 2
+	}
+	return -1
+}
+
+// matchLanguage matches language ranges like "*", "en" and "en-us" with the basic filtering of RFC 4647
+func matchLanguage(rng, offer string) int {
+	switch {
+	case rng == "*":
+		return 0
+	case rng == offer, strings.HasPrefix(offer, rng+"-"):
+		return len(rng)
+	}
+	return -1
+}
+
+// matchToken matches encoding and charset tokens and the "*" wildcard
+func matchToken(rng, offer string) int {
+	switch rng {
+	case "*":
+		return 0
+	case offer:
+		return 1
+	}
+	return -1
+}
+
+func (c *ctx) Accepts(offers ...string) string {
+	return negotiate(c.r.Header.Get("Accept"), offers, matchMediaType)
+}
+
+func (c *ctx) AcceptsLanguages(offers ...string) string {
+	return negotiate(c.r.Header.Get("Accept-Language"), offers, matchLanguage)
+}
+
+func (c *ctx) AcceptsEncodings(offers ...string) string {
+	header := c.r.Header.Get("Accept-Encoding")
+	if offer := negotiate(header, offers, matchToken); offer != "" {
+		return offer
+	}
+
+	// identity is acceptable unless it is excluded explicitly
+	for _, offer := range offers {
+		if strings.EqualFold(offer, "identity") && !excludesIdentity(header) {
+			return offer
+		}
+	}
+	return ""
+}
+
+func (c *ctx) AcceptsCharsets(offers ...string) string {
+	return negotiate(c.r.Header.Get("Accept-Charset"), offers, matchToken)
+}
+
+// excludesIdentity reports whether the Accept-Encoding header rejects the identity encoding with q=0
+func excludesIdentity(header string) bool {
+	for _, rng := range parseAccept(header) {
+		if (rng.value == "identity" || rng.value == "*") && rng.q == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package gale
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccepts(t *testing.T) {
+	app := New()
+
+	newCtx := func(header, value string) Ctx {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if value != "" {
+			r.Header.Set(header, value)
+		}
+		return app.NewTestContext(httptest.NewRecorder(), r, "/")
+	}
+
+	tests := []struct {
+		accept string
+		offers []string
+		want   string
+	}{
+		{"", []string{ContentTypeJSON, ContentTypeHTML}, ContentTypeJSON},
+		{"application/json;q=0.9, text/html", []string{ContentTypeJSON, ContentTypeHTML}, ContentTypeHTML},
+		{"text/*", []string{ContentTypeJSON, ContentTypeHTML}, ContentTypeHTML},
+		{"*/*", []string{ContentTypeXML, ContentTypeJSON}, ContentTypeXML},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", []string{ContentTypeJSON, ContentTypeXML}, ContentTypeXML},
+		{"text/*;q=0.5, text/plain", []string{ContentTypeHTML, ContentTypeText}, ContentTypeText},
+		{"*/*, application/json;q=0", []string{ContentTypeJSON, ContentTypeText}, ContentTypeText},
+		{"image/png", []string{ContentTypeJSON}, ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, newCtx("Accept", tt.accept).Accepts(tt.offers...), tt.accept)
+	}
+
+	assert.Equal(t, "hu", newCtx("Accept-Language", "en-US;q=0.8, hu").AcceptsLanguages("en", "hu"))
+	assert.Equal(t, "en-US", newCtx("Accept-Language", "en").AcceptsLanguages("de", "en-US"))
+	assert.Equal(t, "br", newCtx("Accept-Encoding", "gzip;q=0.5, br").AcceptsEncodings("gzip", "br"))
+	assert.Equal(t, "identity", newCtx("Accept-Encoding", "gzip").AcceptsEncodings("br", "identity"))
+	assert.Equal(t, "", newCtx("Accept-Encoding", "gzip, identity;q=0").AcceptsEncodings("br", "identity"))
+	assert.Equal(t, "utf-8", newCtx("Accept-Charset", "iso-8859-1;q=0.2, *").AcceptsCharsets("iso-8859-1", "utf-8"))
+}
+
+func TestFormatNegotiation(t *testing.T) {
+	app := New()
+	app.Get("/", func(c Ctx) error {
+		return c.Format("hello")
+	})
+
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", ContentTypeJSON},
+		{"text/plain;q=0.5, application/json;q=0.9", ContentTypeJSON},
+		{"text/*", ContentTypeText},
+		{"application/xml;q=0.9, */*;q=0.1", ContentTypeXML},
+		{"image/png", ContentTypeJSON},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+		app.server.ServeHTTP(w, r)
+
+		assert.Contains(t, w.Header().Get("Content-Type"), tt.contentType, tt.accept)
+		assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	}
+}
+
+func TestFormatEncoders(t *testing.T) {
+	type user struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"-"`
+	}
+
+	app := New(&Config{
+		Encoders: map[string]EncoderFunc{
+			"application/x-test": func(w io.Writer, v any) error {
+				_, err := fmt.Fprintf(w, "test:%v", v)
+				return err
+			},
+		},
+	})
+
+	app.Get("/users", func(c Ctx) error {
+		return c.Format([]user{{1, "Alice", "a@b.c"}, {2, "Bob, Jr.", "b@c.d"}})
+	})
+	app.Get("/html", func(c Ctx) error {
+		return c.Format(`<script>alert("x")</script>`)
+	})
+
+	request := func(path, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		app.server.ServeHTTP(w, r)
+		return w
+	}
+
+	w := request("/users", "text/csv")
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Equal(t, "id,name\n1,Alice\n2,\"Bob, Jr.\"\n", w.Body.String())
+
+	w = request("/users", "application/yaml")
+	assert.Equal(t, "application/yaml", w.Header().Get("Content-Type"))
+	assert.Equal(t, "- id: 1\n  name: Alice\n- id: 2\n  name: Bob, Jr.\n", w.Body.String())
+
+	w = request("/users", "application/x-test")
+	assert.Equal(t, "application/x-test", w.Header().Get("Content-Type"))
+	assert.Equal(t, "test:[{1 Alice a@b.c} {2 Bob, Jr. b@c.d}]", w.Body.String())
+
+	app.RegisterEncoder("application/x-later", func(w io.Writer, v any) error {
+		_, err := io.WriteString(w, "later")
+		return err
+	})
+
+	w = request("/users", "application/x-later")
+	assert.Equal(t, "application/x-later", w.Header().Get("Content-Type"))
+	assert.Equal(t, "later", w.Body.String())
+
+	w = request("/users", "*/*")
+	assert.Equal(t, ContentTypeJSON, w.Header().Get("Content-Type"))
+	assert.Equal(t, `[{"id":1,"name":"Alice"},{"id":2,"name":"Bob, Jr."}]`, w.Body.String())
+
+	w = request("/html", "text/html")
+	assert.Equal(t, "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</p>", w.Body.String())
+}
+
+func TestRegisterEncoderWhileServing(t *testing.T) {
+	app := New()
+	app.Get("/", func(c Ctx) error {
+		return c.Format("ok")
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			app.RegisterEncoder(fmt.Sprintf("application/x-test-%d", i), encodeText)
+		}()
+		go func() {
+			defer wg.Done()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", "text/plain")
+			w := httptest.NewRecorder()
+			app.server.ServeHTTP(w, r)
+			assert.Equal(t, "ok", w.Body.String())
+		}()
+	}
+	wg.Wait()
+
+	_, types := app.encoders.get()
+	assert.Len(t, types, len(defaultEncoderTypes)+10)
+}
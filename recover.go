@@ -0,0 +1,187 @@
+package gale
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// PanicError is the error created from a recovered panic
+type PanicError struct {
+	// Value is the value passed to panic
+	Value any
+	// Stack is the stack of the panicking goroutine, starting at the panic
+	Stack []StackFrame
+}
+
+// StackFrame is a single frame of a stack trace
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+func newPanicError(v any) *PanicError {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []StackFrame
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, StackFrame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		})
+
+		if !more {
+			break
+		}
+	}
+
+	// skip the frames of the panic handling itself
+	for i, frame := range stack {
+		if frame.Function == "runtime.gopanic" {
+			stack = stack[i+1:]
+			break
+		}
+	}
+
+	return &PanicError{Value: v, Stack: stack}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// recover turns a panic of the request chain into an error.
+// In development mode browsers get an error page with the stack trace,
+// in production mode the error handler gets a sanitized error while the OnError hooks get the panic.
+func (s *server) recover(ctx Ctx) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	// the standard way to abort a response, let net/http handle it
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	err := newPanicError(v)
+	ctx.Logger().Error("panic recovered", "error", err)
+
+	// nothing can be sent if the response is already on its way
+	if ctx.Response().Written() && !ctx.Response().Buffered() {
+		s.app.runErrorHooks(ctx, err)
+		return
+	}
+
+	// the partial buffered response is replaced by a clean error response
+	ctx.resetResponse()
+
+	if s.app.config.Mode == Development {
+		if !strings.Contains(ctx.Header().Get("Accept"), ContentTypeHTML) {
+			s.sendError(ctx, err)
+			return
+		}
+
+		s.app.runErrorHooks(ctx, err)
+		handleError(ctx, renderPanicPage(ctx, err))
+		return
+	}
+
+	s.app.runErrorHooks(ctx, err)
+	handleError(ctx, s.app.config.ErrorHandler(ctx, NewError(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))))
+}
+
+type sourceLine struct {
+	Number  int
+	Code    string
+	Current bool
+}
+
+// renderPanicPage sends the development error page of the panic
+func renderPanicPage(c Ctx, err *PanicError) error {
+	var (
+		source     []sourceLine
+		sourceFile string
+	)
+
+	if len(err.Stack) > 0 {
+		sourceFile = fmt.Sprintf("%s:%d", err.Stack[0].File, err.Stack[0].Line)
+		source = readSource(err.Stack[0].File, err.Stack[0].Line, 5)
+	}
+
+	style, _ := uiFiles.ReadFile("ui/css/build.css")
+
+	locals := make(map[string]string, len(c.Locals()))
+	for key, value := range c.Locals() {
+		locals[key] = fmt.Sprintf("%+v", value)
+	}
+
+	var b bytes.Buffer
+	e := renderUIPage(&b, "error", Map{
+		"Title":       err.Error(),
+		"InlineStyle": template.CSS(style),
+		"Status":      http.StatusInternalServerError,
+		"Error":       err.Error(),
+		"Source":      source,
+		"SourceFile":  sourceFile,
+		"Frames":      err.Stack,
+		"Method":      c.Method(),
+		"URL":         c.URL().String(),
+		"Route":       c.Route(),
+		"Params":      c.Params(),
+		"Headers":     c.Header().GetAll(),
+		"Locals":      locals,
+	})
+	if e != nil {
+		return e
+	}
+
+	return c.Status(http.StatusInternalServerError).ContentType(ContentTypeHTML).Send(b.Bytes())
+}
+
+// readSource returns the lines of the file around the given line
+func readSource(file string, line, around int) []sourceLine {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []sourceLine
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		if n < line-around {
+			continue
+		}
+		if n > line+around {
+			break
+		}
+
+		lines = append(lines, sourceLine{
+			Number:  n,
+			Code:    scanner.Text(),
+			Current: n == line,
+		})
+	}
+
+	return lines
+}
@@ -0,0 +1,149 @@
+package gale
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request id attached to the context by the Gale server
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// UUIDRequestID generates random UUIDv4 request ids, it is the default generator
+func UUIDRequestID() string {
+	return uuid.New().String()
+}
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDRequestID generates lexicographically sortable ULID request ids
+func ULIDRequestID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	binary.BigEndian.PutUint16(b[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:6], uint32(ms))
+	_, _ = rand.Read(b[6:])
+
+	// encode the 128 bits as 26 base32 characters, the first character holds the top 3 bits
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	out := make([]byte, 26)
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockfordAlphabet[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
+
+// CounterRequestID returns a generator that is cheaper than the random ones,
+// the ids are a random per process prefix and an incrementing counter, like "5f1c9a2e-42"
+func CounterRequestID() RequestIDGenerator {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	prefix := hex.EncodeToString(b[:]) + "-"
+
+	var n atomic.Uint64
+	return func() string {
+		return prefix + strconv.FormatUint(n.Add(1), 10)
+	}
+}
+
+// withRequestID resolves the id of the request, echoes it in the response header and attaches it to the request context.
+// The request is returned unchanged when it already has an id, like in mounted applications.
+func (g *Gale) withRequestID(w http.ResponseWriter, r *http.Request) (*http.Request, string) {
+	if id, ok := RequestIDFromContext(r.Context()); ok {
+		return r, id
+	}
+
+	conf := g.config.RequestID
+	id := g.incomingRequestID(r)
+	if id == "" {
+		id = conf.Generator()
+	}
+
+	w.Header().Set(conf.Header, id)
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)), id
+}
+
+// incomingRequestID returns the trusted id sent by the client or an upstream proxy
+func (g *Gale) incomingRequestID(r *http.Request) string {
+	conf := g.config.RequestID
+
+	if !conf.IgnoreHeader {
+		if id := r.Header.Get(conf.Header); validRequestID(id) {
+			return id
+		}
+	}
+
+	if !conf.IgnoreTraceparent {
+		if id, ok := traceID(r.Header.Get("traceparent")); ok {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// validRequestID reports whether an incoming id is safe to log and echo
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("-_.:/+=@", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// traceID returns the trace id of a W3C traceparent header, like "00-<trace-id>-<parent-id>-01"
+func traceID(traceparent string) (string, bool) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", false
+	}
+
+	// version ff is invalid, and version 00 has exactly four fields
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return "", false
+	}
+
+	for _, part := range parts[:4] {
+		if !isLowerHex(part) {
+			return "", false
+		}
+	}
+
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", false
+	}
+
+	return parts[1], true
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+package gale
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// ResponseWriter is the http.ResponseWriter of the Ctx, it records the status, size and timing of the response.
+// In buffered mode the response is kept in memory until the request is finished,
+// so middlewares and hooks can still rewrite the headers, status and body after the handler.
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	io.ReaderFrom
+
+	// Unwrap returns the original http.ResponseWriter
+	Unwrap() http.ResponseWriter
+	// Status returns the response status code, 0 if nothing is written yet
+	Status() int
+	// Size returns the number of body bytes written
+	Size() int64
+	// Written reports whether the response header is written
+	Written() bool
+	// Duration returns the time elapsed since the request started
+	Duration() time.Duration
+
+	// Buffer turns on buffered mode, it must be called before the response is written
+	Buffer()
+	// Buffered reports whether the response is buffered
+	Buffered() bool
+	// Body returns the buffered response body
+	Body() []byte
+	// SetBody replaces the buffered response body
+	SetBody(b []byte)
+	// SetStatus replaces the buffered response status code
+	SetStatus(code int)
+	// Commit writes the buffered response to the client,
+	// it is called automatically when the request is finished
+	Commit() error
+}
+
+type responseWriter struct {
+	w     http.ResponseWriter
+	start time.Time
+	// header are the headers set by the framework before the request is handled, like the request id
+	header http.Header
+
+	status int
+	size   int64
+
+	buffered  bool
+	committed bool
+	buf       bytes.Buffer
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{
+		w:      w,
+		header: w.Header().Clone(),
+		start:  time.Now(),
+	}
+}
+
+func (r *responseWriter) Header() http.Header {
+	return r.w.Header()
+}
+
+func (r *responseWriter) WriteHeader(code int) {
+	if r.status != 0 {
+		return
+	}
+
+	r.status = code
+	if !r.buffered {
+		r.w.WriteHeader(code)
+	}
+}
+
+func (r *responseWriter) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	if r.buffered {
+		n, err := r.buf.Write(b)
+		r.size += int64(n)
+		return n, err
+	}
+
+	n, err := r.w.Write(b)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	var (
+		n   int64
+		err error
+	)
+
+	if r.buffered {
+		n, err = r.buf.ReadFrom(src)
+	} else {
+		// io.Copy uses the ReadFrom of the original writer when it has one, like for sendfile
+		n, err = io.Copy(r.w, src)
+	}
+
+	r.size += n
+	return n, err
+}
+
+func (r *responseWriter) Flush() {
+	if r.buffered {
+		return
+	}
+
+	if f, ok := r.w.(http.Flusher); ok {
+		if r.status == 0 {
+			r.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+func (r *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := r.w.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+func (r *responseWriter) Unwrap() http.ResponseWriter {
+	return r.w
+}
+
+func (r *responseWriter) Status() int {
+	return r.status
+}
+
+func (r *responseWriter) Size() int64 {
+	return r.size
+}
+
+func (r *responseWriter) Written() bool {
+	return r.status != 0
+}
+
+func (r *responseWriter) Duration() time.Duration {
+	return time.Since(r.start)
+}
+
+func (r *responseWriter) Buffer() {
+	if r.status == 0 {
+		r.buffered = true
+	}
+}
+
+func (r *responseWriter) Buffered() bool {
+	return r.buffered
+}
+
+func (r *responseWriter) Body() []byte {
+	return r.buf.Bytes()
+}
+
+func (r *responseWriter) SetBody(b []byte) {
+	r.buf.Reset()
+	r.buf.Write(b)
+	r.size = int64(len(b))
+}
+
+func (r *responseWriter) SetStatus(code int) {
+	if r.buffered {
+		r.status = code
+	}
+}
+
+// reset discards the buffered status and body and restores the headers set by the framework
+func (r *responseWriter) reset() {
+	r.buf.Reset()
+	r.size = 0
+	r.status = 0
+
+	header := r.w.Header()
+	clear(header)
+	for key, values := range r.header {
+		header[key] = slices.Clone(values)
+	}
+}
+
+func (r *responseWriter) Commit() error {
+	if !r.buffered || r.committed {
+		return nil
+	}
+	r.committed = true
+
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	if r.buf.Len() > 0 {
+		r.w.Header().Set("Content-Length", strconv.Itoa(r.buf.Len()))
+	}
+
+	r.w.WriteHeader(r.status)
+	_, err := r.w.Write(r.buf.Bytes())
+	return err
+}
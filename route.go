@@ -1,18 +1,23 @@
 package gale
 
 import (
-	"os"
+	"fmt"
 	"regexp"
 	"strings"
-
-	"github.com/fatih/color"
 )
 
 type Route interface {
 	Name(name string)
 	GetName() string
+	// BodyLimit sets the maximum size of the request body in bytes, it overrides Config.BodyLimit
+	// -1 means no limit
+	BodyLimit(limit int64)
+	// GetBodyLimit returns the body limit of the route, 0 means Config.BodyLimit is used
+	GetBodyLimit() int64
 	Method() string
 	Path() string
+	// Host returns the host pattern of the route, empty for the default host
+	Host() string
 	NormalizedPaths() []string
 
 	Handler() HandlerFunc
@@ -20,28 +25,37 @@ type Route interface {
 
 	// allRoute returns all possible routes and their parts
 	allRoutesParts() [][]RoutePart
-	comparePath(router CompleteRouter, path string) (bool, map[string]string)
 }
 
 type RoutePart struct {
 	Static     bool     `json:"static"`
+	CatchAll   bool     `json:"catch_all,omitempty"`
 	Value      string   `json:"value"`
 	Validators []string `json:"validators,omitempty"`
+
+	// validators are the resolved Validators, set when the route is registered
+	validators []RouteParamValidatorFunc
+	// converters are the converters of the built-in validators, nil for the other validators
+	converters []paramConverter
 }
 
 // Implementing route
 
 type route struct {
 	name        string
+	bodyLimit   int64
+	host        string
 	method      string
 	rawPath     string
 	paths       []string
 	handler     HandlerFunc
 	middlewares []MiddlewareFunc
 	parts       [][]RoutePart
+	// err is the error of parsing the path
+	err error
 }
 
-func newRoute(method, path string, handler HandlerFunc, middlewares []MiddlewareFunc) Route {
+func newRoute(method, path string, handler HandlerFunc, middlewares []MiddlewareFunc) *route {
 	routePaths := createOptionalRoutes(path)
 	r := &route{
 		method:      method,
@@ -49,7 +63,7 @@ func newRoute(method, path string, handler HandlerFunc, middlewares []Middleware
 		paths:       routePaths,
 		handler:     handler,
 		middlewares: middlewares,
-		parts:       make([][]RoutePart, len(routePaths)),
+		parts:       make([][]RoutePart, 0, len(routePaths)),
 	}
 	r.parse()
 	return r
@@ -63,6 +77,14 @@ func (r *route) GetName() string {
 	return r.name
 }
 
+func (r *route) BodyLimit(limit int64) {
+	r.bodyLimit = limit
+}
+
+func (r *route) GetBodyLimit() int64 {
+	return r.bodyLimit
+}
+
 func (r *route) Method() string {
 	return r.method
 }
@@ -71,24 +93,16 @@ func (r *route) Path() string {
 	return r.rawPath
 }
 
+func (r *route) Host() string {
+	return r.host
+}
+
 func (r *route) NormalizedPaths() []string {
 	var paths []string
 
-	for _, route := range r.parts {
-		var path strings.Builder
-
-		for _, part := range route {
-			path.WriteString("/")
-			if part.Static {
-				path.WriteString(part.Value)
-			} else {
-				path.WriteString("{" + part.Value + "}")
-			}
-		}
-
-		pathStr := path.String()
-		if pathStr != "" {
-			paths = append(paths, path.String())
+	for _, parts := range r.parts {
+		if path := normalizePath(parts); path != "" {
+			paths = append(paths, path)
 		}
 	}
 
@@ -109,33 +123,55 @@ func (r *route) Middlewares() []MiddlewareFunc {
 
 func (r *route) parse() {
 	for _, path := range r.paths {
-		p := r.parsePath(path)
+		p, err := r.parsePath(path)
+		if err != nil {
+			r.err = err
+			return
+		}
 		r.parts = append(r.parts, p)
 	}
 }
 
-func (r *route) parsePath(path string) []RoutePart {
+func (r *route) parsePath(path string) ([]RoutePart, error) {
 	path = strings.TrimSpace(strings.Trim(path, "/"))
 	parts := []RoutePart{}
+	segments := strings.Split(path, "/")
+
+	for i, part := range segments {
+		if part == "*" || strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if part == "*" {
+				part = "{*...}"
+			}
 
-	for _, part := range strings.Split(path, "/") {
-		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
 			part = part[1 : len(part)-1]
 			var validators []string
 
 			if strings.Contains(part, "@") {
 				parts := strings.SplitN(part, "@", 2)
-				if len(parts) != 2 {
-					color.Red("Invalid route path: '%s'", path)
-					os.Exit(1)
+				if parts[1] == "" {
+					return nil, fmt.Errorf("invalid route path '%s', missing validator name", path)
 				}
 
 				part = parts[0]
-				validators = strings.Split(parts[1], ",")
+				validators = splitValidators(parts[1])
+			}
+
+			catchAll := strings.HasSuffix(part, "...")
+			if catchAll {
+				part = strings.TrimSuffix(part, "...")
+
+				if i != len(segments)-1 {
+					return nil, fmt.Errorf("invalid route path '%s', catch-all parameters must be the last segment", path)
+				}
+			}
+
+			if part == "" {
+				return nil, fmt.Errorf("invalid route path '%s', missing param name", path)
 			}
 
 			parts = append(parts, RoutePart{
 				Static:     false,
+				CatchAll:   catchAll,
 				Value:      part,
 				Validators: validators,
 			})
@@ -147,62 +183,27 @@ func (r *route) parsePath(path string) []RoutePart {
 		}
 	}
 
-	return parts
-}
-
-func (r *route) comparePath(router CompleteRouter, path string) (bool, map[string]string) {
-	path = strings.Trim(path, "/")
-	pathParts := strings.Split(path, "/")
-
-	for _, route := range r.allRoutesParts() {
-		if len(pathParts) != len(route) {
-			continue
-		}
-
-		ok, params := r.compareSinglePath(router, route, pathParts)
-		if !ok {
-			continue
-		}
-
-		return ok, params
-	}
-
-	return false, nil
+	return parts, nil
 }
 
-func (r *route) compareSinglePath(router CompleteRouter, route []RoutePart, pathParts []string) (bool, map[string]string) {
-	params := make(map[string]string)
-
-	if len(route) == 0 && len(pathParts) == 0 {
-		return true, nil
-	}
-
-	for i, part := range route {
-		if part.Static {
-			if part.Value != pathParts[i] {
-				return false, nil
-			}
-		} else {
-			value := pathParts[i]
-
-			for _, v := range part.Validators {
-				fn, err := router.getValidator(v)
-				if err != nil {
-					color.Red("Validator not found: %s", v)
-					os.Exit(1)
-				}
-
-				value, err = fn(value)
-				if err != nil {
-					return false, nil
-				}
-			}
+func normalizePath(parts []RoutePart) string {
+	var path strings.Builder
 
-			params[part.Value] = value
+	for _, part := range parts {
+		path.WriteString("/")
+		switch {
+		case part.Static:
+			path.WriteString(part.Value)
+		case part.CatchAll && part.Value == "*":
+			path.WriteString("*")
+		case part.CatchAll:
+			path.WriteString("{" + part.Value + "...}")
+		default:
+			path.WriteString("{" + part.Value + "}")
 		}
 	}
 
-	return true, params
+	return path.String()
 }
 
 func createOptionalRoutes(route string) []string {
@@ -213,7 +214,7 @@ func createOptionalRoutes(route string) []string {
 	matches := re.FindAllString(route, -1)
 
 	if len(matches) > 0 {
-		routeWithParams := strings.Replace(route, "?", "", -1)
+		routeWithParams := strings.ReplaceAll(route, "}?", "}")
 		routes = append(routes, routeWithParams)
 
 		for _, match := range matches {
@@ -225,7 +226,7 @@ func createOptionalRoutes(route string) []string {
 				routeWithoutParam = strings.TrimSuffix(routeWithoutParam, "/")
 			}
 
-			routeWithoutParam = strings.Replace(routeWithoutParam, "?", "", -1)
+			routeWithoutParam = strings.ReplaceAll(routeWithoutParam, "}?", "}")
 
 			routes = append(routes, routeWithoutParam)
 		}
@@ -2,22 +2,118 @@ package gale
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
 
+var (
+	alphaRegex        = regexp.MustCompile("^[a-zA-Z]+$")
+	alphaNumericRegex = regexp.MustCompile("^[a-zA-Z0-9]+$")
+)
+
+// paramConverter converts a route param while it is validated, the converted value is reused by gale.Param
+type paramConverter func(value string) (any, error)
+
+// paramConverters are the built-in validators that convert the value, by validator name
+var paramConverters = map[string]paramConverter{
+	"int":  convertInt,
+	"bool": convertBool,
+	"uuid": convertUUID,
+}
+
+func convertInt(value string) (any, error) {
+	return strconv.Atoi(value)
+}
+
+func convertBool(value string) (any, error) {
+	return strconv.ParseBool(value)
+}
+
+func convertUUID(value string) (any, error) {
+	return uuid.Parse(value)
+}
+
+// runValidators validates a param value, the value converted by a built-in validator is returned too.
+// The converted value is dropped when a later validator changes the value.
+func runValidators(value string, fns []RouteParamValidatorFunc, converters []paramConverter) (string, any, error) {
+	var (
+		typed     any
+		typedFrom string
+	)
+
+	for i, fn := range fns {
+		if i < len(converters) && converters[i] != nil {
+			v, err := converters[i](value)
+			if err != nil {
+				return "", nil, err
+			}
+			typed, typedFrom = v, value
+			continue
+		}
+
+		var err error
+		if value, err = fn(value); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if typed != nil && typedFrom != value {
+		typed = nil
+	}
+	return value, typed, nil
+}
+
 func registerDefaultRouteValidators(router RouterParamValidator) {
 	router.RegisterRouteParamValidator("int", validateInt)
 	router.RegisterRouteParamValidator("bool", validateBool)
 	router.RegisterRouteParamValidator("uuid", validateUUIDv4)
 	router.RegisterRouteParamValidator("alpha", validateAlpha)
 	router.RegisterRouteParamValidator("alphanumeric", validateAlphaNumeric)
+
+	router.RegisterRouteParamValidatorFactory("min", validateMin)
+	router.RegisterRouteParamValidatorFactory("max", validateMax)
+	router.RegisterRouteParamValidatorFactory("len", validateLen)
+	router.RegisterRouteParamValidatorFactory("in", validateIn)
+	router.RegisterRouteParamValidatorFactory("regex", validateRegex)
+}
+
+// splitValidators splits the validators of a param like "int,min:1,max:10",
+// regex validators take the rest of the list, so their patterns can contain commas
+func splitValidators(spec string) []string {
+	var validators []string
+
+	for spec != "" {
+		if strings.HasPrefix(spec, "regex:") {
+			validators = append(validators, spec)
+			break
+		}
+
+		v, rest, _ := strings.Cut(spec, ",")
+		validators = append(validators, v)
+		spec = rest
+	}
+
+	return validators
+}
+
+// noArgs creates a factory for a validator without arguments
+func noArgs(name string, fn RouteParamValidatorFunc) RouteParamValidatorFactory {
+	return func(args string) (RouteParamValidatorFunc, error) {
+		if args != "" {
+			return nil, fmt.Errorf("validator '%s' does not accept arguments", name)
+		}
+		return fn, nil
+	}
 }
 
 func validateInt(value string) (string, error) {
-	_, err := strconv.Atoi(value)
+	_, err := convertInt(value)
 	if err != nil {
 		return "", err
 	}
@@ -25,7 +121,7 @@ func validateInt(value string) (string, error) {
 }
 
 func validateBool(value string) (string, error) {
-	_, err := strconv.ParseBool(value)
+	_, err := convertBool(value)
 	if err != nil {
 		return "", err
 	}
@@ -33,7 +129,7 @@ func validateBool(value string) (string, error) {
 }
 
 func validateUUIDv4(value string) (string, error) {
-	_, err := uuid.Parse(value)
+	_, err := convertUUID(value)
 	if err != nil {
 		return "", err
 	}
@@ -41,7 +137,7 @@ func validateUUIDv4(value string) (string, error) {
 }
 
 func validateAlpha(value string) (string, error) {
-	ok := regexp.MustCompile("^[a-zA-Z]+$").MatchString(value)
+	ok := alphaRegex.MatchString(value)
 	if !ok {
 		return "", errors.New("param is not alpha")
 	}
@@ -49,9 +145,96 @@ func validateAlpha(value string) (string, error) {
 }
 
 func validateAlphaNumeric(value string) (string, error) {
-	ok := regexp.MustCompile("^[a-zA-Z0-9]+$").MatchString(value)
+	ok := alphaNumericRegex.MatchString(value)
 	if !ok {
 		return "", errors.New("param is not alphanumeric")
 	}
 	return value, nil
 }
+
+// validateMin accepts numbers greater than or equal to the argument, like {id@int,min:1}
+func validateMin(args string) (RouteParamValidatorFunc, error) {
+	limit, err := strconv.ParseFloat(args, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid min argument '%s'", args)
+	}
+
+	return func(value string) (string, error) {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || n < limit {
+			return "", fmt.Errorf("param must be at least %s", args)
+		}
+		return value, nil
+	}, nil
+}
+
+// validateMax accepts numbers less than or equal to the argument, like {id@int,max:1000}
+func validateMax(args string) (RouteParamValidatorFunc, error) {
+	limit, err := strconv.ParseFloat(args, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid max argument '%s'", args)
+	}
+
+	return func(value string) (string, error) {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || n > limit {
+			return "", fmt.Errorf("param must be at most %s", args)
+		}
+		return value, nil
+	}, nil
+}
+
+// validateLen accepts values with an exact length or a length in a range, like {code@len:3} or {slug@len:3-64}
+func validateLen(args string) (RouteParamValidatorFunc, error) {
+	minArg, maxArg, isRange := strings.Cut(args, "-")
+	if !isRange {
+		maxArg = minArg
+	}
+
+	minLen, err := strconv.Atoi(minArg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid len argument '%s'", args)
+	}
+
+	maxLen, err := strconv.Atoi(maxArg)
+	if err != nil || maxLen < minLen {
+		return nil, fmt.Errorf("invalid len argument '%s'", args)
+	}
+
+	return func(value string) (string, error) {
+		if l := utf8.RuneCountInString(value); l < minLen || l > maxLen {
+			return "", fmt.Errorf("param length must be %s", args)
+		}
+		return value, nil
+	}, nil
+}
+
+// validateIn accepts one of the values separated by "|", like {sort@in:asc|desc}
+func validateIn(args string) (RouteParamValidatorFunc, error) {
+	if args == "" {
+		return nil, errors.New("missing in argument")
+	}
+
+	values := strings.Split(args, "|")
+	return func(value string) (string, error) {
+		if !slices.Contains(values, value) {
+			return "", fmt.Errorf("param must be one of %s", args)
+		}
+		return value, nil
+	}, nil
+}
+
+// validateRegex accepts the values matching the pattern, like {code@regex:^[A-Z]{3}$}
+func validateRegex(args string) (RouteParamValidatorFunc, error) {
+	re, err := regexp.Compile(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex argument: %w", err)
+	}
+
+	return func(value string) (string, error) {
+		if !re.MatchString(value) {
+			return "", errors.New("param does not match " + args)
+		}
+		return value, nil
+	}, nil
+}
@@ -2,11 +2,13 @@ package gale
 
 import (
 	"errors"
+	"fmt"
+	"maps"
 	"net/http"
-	"os"
+	"net/url"
 	"path"
-
-	"github.com/fatih/color"
+	"slices"
+	"strings"
 )
 
 // CompleteRouter is an interface that combines the Router and RouterParamValidator interfaces.
@@ -17,9 +19,19 @@ type CompleteRouter interface {
 	Dump()
 	// Export is an alias to exportRoutes.
 	Export() []Route
+	// Host returns a router whose routes only match requests with the given Host header.
+	// The pattern can contain params like "{tenant}.example.com", they are available with Ctx.Param.
+	Host(pattern string) Router
+	// URL builds the path of a named route, params that are not part of the route are added as query parameters.
+	URL(name string, params Map) (string, error)
 
 	exportRoutes() []Route
+	registrationErrors() []error
 	getValidator(name string) (RouteParamValidatorFunc, error)
+	mount(prefix string, h http.Handler, app *Gale, middlewares []MiddlewareFunc) Route
+	mountedApps() []*Gale
+	match(host, method, path string) (Route, map[string]string, map[string]any)
+	allowedMethods(host, path string) []string
 }
 
 // Router is an interface that defines the methods for registering routes.
@@ -47,42 +59,151 @@ type Router interface {
 
 	// Group creates a new router group with a common prefix and optional middlewares.
 	Group(prefix string, middlewares ...MiddlewareFunc) Router
+
+	// Mount registers a http.Handler for every request under the prefix.
+	// The handler receives the request path without the prefix.
+	Mount(prefix string, h http.Handler, middlewares ...MiddlewareFunc) Route
 }
 
 // RouterParamValidator is an interface that allows you to register custom route parameter validators.
 type RouterParamValidator interface {
 	// RouterParamValidator is an interface that allows you to register custom route parameter validators.
 	// default validators: int, bool, uuid, alpha, alphanumeric.
+	// Validators must be registered before the routes that use them.
 	RegisterRouteParamValidator(name string, fn RouteParamValidatorFunc)
+	// RegisterRouteParamValidatorFactory registers a validator with arguments, like {id@min:1}.
+	// default validators: min, max, len, in, regex.
+	RegisterRouteParamValidatorFactory(name string, factory RouteParamValidatorFactory)
 }
 
 // Implement the router
 
 type router struct {
 	routes     []Route
-	validators map[string]RouteParamValidatorFunc
+	trees      map[string]*node
+	validators map[string]RouteParamValidatorFactory
+
+	host      string
+	hostParts []RoutePart
+	hosts     []*router
+
+	// mounts are the mounted applications in registration order
+	mounts []*mountedApp
+
+	// errs are the registration errors, shared with the host routers
+	errs *[]error
+
+	// onRoute is called with every registered route
+	onRoute func(route Route)
+	// onWarn reports the registration problems that are not errors, like shadowed routes
+	onWarn func(msg string, args ...any)
 }
 
 func newRouter() CompleteRouter {
 	return &router{
 		routes:     []Route{},
-		validators: map[string]RouteParamValidatorFunc{},
+		trees:      map[string]*node{},
+		validators: map[string]RouteParamValidatorFactory{},
+		errs:       &[]error{},
 	}
 }
 
+// RouteError is an error of registering a route
+type RouteError struct {
+	Method string
+	Path   string
+	Err    error
+}
+
+func (e *RouteError) Error() string {
+	return fmt.Sprintf("route \"%s %s\": %v", e.Method, e.Path, e.Err)
+}
+
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
 func (r *router) Export() []Route {
 	return r.exportRoutes()
 }
 
+func (r *router) Host(pattern string) Router {
+	for _, h := range r.hosts {
+		if h.host == pattern {
+			return h
+		}
+	}
+
+	h := &router{
+		routes:     []Route{},
+		trees:      map[string]*node{},
+		validators: r.validators,
+		host:       pattern,
+		hostParts:  parseHost(pattern),
+		errs:       r.errs,
+		onRoute:    r.onRoute,
+		onWarn:     r.onWarn,
+	}
+
+	if err := r.resolveValidators(h.hostParts); err != nil {
+		r.addError(fmt.Errorf("host \"%s\": %w", pattern, err))
+	}
+
+	// static hosts are tried before the ones with params
+	i := len(r.hosts)
+	if hostHasParams(h.hostParts) {
+		r.hosts = append(r.hosts, h)
+	} else {
+		for i > 0 && hostHasParams(r.hosts[i-1].hostParts) {
+			i--
+		}
+		r.hosts = slices.Insert(r.hosts, i, h)
+	}
+
+	return h
+}
+
 func (r *router) Add(method, path string, handler HandlerFunc, middlewares ...MiddlewareFunc) Route {
+	route := newRoute(method, path, handler, middlewares)
+	route.host = r.host
+
+	// invalid routes are returned but not registered, the error is returned by Gale.Validate
+	if route.err != nil {
+		r.addError(&RouteError{Method: method, Path: path, Err: route.err})
+		return route
+	}
+
+	for _, parts := range route.parts {
+		if err := r.resolveValidators(parts); err != nil {
+			r.addError(&RouteError{Method: method, Path: path, Err: err})
+			return route
+		}
+	}
+
 	if r.routeExists(method, path) {
-		color.Red("Error: route \"%s %s\" already exists.", method, path)
-		os.Exit(1)
+		r.addError(&RouteError{Method: method, Path: path, Err: errors.New("route already exists")})
+		return route
 	}
 
-	route := newRoute(method, path, handler, middlewares)
 	r.routes = append(r.routes, route)
 
+	tree, ok := r.trees[method]
+	if !ok {
+		tree = newTree()
+		r.trees[method] = tree
+	}
+
+	for _, shadowed := range tree.insert(route) {
+		if r.onWarn != nil {
+			r.onWarn("route is unreachable, it is shadowed by another route",
+				"route", method+" "+shadowed.path, "shadowed_by", shadowed.by.Method()+" "+shadowed.by.Path())
+		}
+	}
+
+	if r.onRoute != nil {
+		r.onRoute(route)
+	}
+
 	return route
 }
 
@@ -136,29 +257,287 @@ func (r *router) Group(prefix string, middlewares ...MiddlewareFunc) Router {
 	}
 }
 
+func (r *router) Mount(prefix string, h http.Handler, middlewares ...MiddlewareFunc) Route {
+	return r.mount(prefix, h, nil, middlewares)
+}
+
+func (r *router) mount(prefix string, h http.Handler, app *Gale, middlewares []MiddlewareFunc) Route {
+	prefix = "/" + strings.Trim(prefix, "/")
+
+	route := r.Add("*", path.Join(prefix, "{path...}")+"?", mountHandler(prefix, h), middlewares...)
+	if app != nil {
+		r.mounts = append(r.mounts, &mountedApp{route: route, prefix: prefix, app: app})
+	}
+
+	return route
+}
+
+func (r *router) mountedApps() []*Gale {
+	var apps []*Gale
+	for _, m := range r.mounts {
+		apps = append(apps, m.app)
+	}
+	for _, h := range r.hosts {
+		apps = append(apps, h.mountedApps()...)
+	}
+	return apps
+}
+
 func (r *router) Dump() {
-	logRoutes(r.routes)
+	logRoutes(r.exportRoutes())
+}
+
+func (r *router) URL(name string, params Map) (string, error) {
+	var route Route
+	for _, rt := range r.exportRoutes() {
+		if rt.GetName() == name {
+			route = rt
+			break
+		}
+	}
+
+	if route == nil {
+		return "", errors.New("route '" + name + "' does not exists")
+	}
+
+	values := make(map[string]string, len(params))
+	for key, value := range params {
+		values[key] = fmt.Sprint(value)
+	}
+
+	// use the path variant that uses the most of the supplied params,
+	// optional segments are dropped when they are not supplied
+	var (
+		parts   []RoutePart
+		missing string
+		used    = -1
+	)
+
+	for _, p := range route.allRoutesParts() {
+		names := paramNames(p)
+
+		i := slices.IndexFunc(names, func(name string) bool {
+			_, ok := values[name]
+			return !ok
+		})
+		if i != -1 {
+			if missing == "" {
+				missing = names[i]
+			}
+			continue
+		}
+
+		if len(names) > used {
+			parts, used = p, len(names)
+		}
+	}
+
+	if parts == nil {
+		return "", errors.New("missing param '" + missing + "' for route '" + name + "'")
+	}
+
+	var b strings.Builder
+	for _, part := range parts {
+		b.WriteString("/")
+
+		if part.Static {
+			b.WriteString(part.Value)
+			continue
+		}
+
+		value := values[part.Value]
+		delete(values, part.Value)
+
+		for _, fn := range part.validators {
+			if _, err := fn(value); err != nil {
+				return "", fmt.Errorf("invalid param '%s' for route '%s': %w", part.Value, name, err)
+			}
+		}
+
+		if part.CatchAll {
+			segments := strings.Split(value, "/")
+			for i, segment := range segments {
+				segments[i] = url.PathEscape(segment)
+			}
+			b.WriteString(strings.Join(segments, "/"))
+		} else {
+			b.WriteString(url.PathEscape(value))
+		}
+	}
+
+	if len(values) > 0 {
+		query := url.Values{}
+		for key, value := range values {
+			query.Set(key, value)
+		}
+		b.WriteString("?" + query.Encode())
+	}
+
+	return b.String(), nil
 }
 
 func (r *router) RegisterRouteParamValidator(name string, fn RouteParamValidatorFunc) {
+	r.RegisterRouteParamValidatorFactory(name, noArgs(name, fn))
+}
+
+func (r *router) RegisterRouteParamValidatorFactory(name string, factory RouteParamValidatorFactory) {
 	if _, ok := r.validators[name]; ok {
-		color.Red("Error: route param validator \"%s\" already exists.", name)
-		os.Exit(1)
+		r.addError(fmt.Errorf("route param validator \"%s\" already exists", name))
+		return
 	}
 
-	r.validators[name] = fn
+	r.validators[name] = factory
 }
 
-func (r *router) getValidator(name string) (RouteParamValidatorFunc, error) {
-	v, ok := r.validators[name]
+// resolveValidators looks up the validators of the params, so they are not resolved on every request
+func (r *router) resolveValidators(parts []RoutePart) error {
+	for i, part := range parts {
+		if len(part.Validators) == 0 {
+			continue
+		}
+
+		fns := make([]RouteParamValidatorFunc, 0, len(part.Validators))
+		converters := make([]paramConverter, 0, len(part.Validators))
+		for _, v := range part.Validators {
+			fn, err := r.getValidator(v)
+			if err != nil {
+				return err
+			}
+			fns = append(fns, fn)
+			converters = append(converters, paramConverters[v])
+		}
+		parts[i].validators = fns
+		parts[i].converters = converters
+	}
+
+	return nil
+}
+
+func (r *router) addError(err error) {
+	*r.errs = append(*r.errs, err)
+}
+
+func (r *router) registrationErrors() []error {
+	return *r.errs
+}
+
+// getValidator creates the validator of a spec like "int" or "min:1"
+func (r *router) getValidator(spec string) (RouteParamValidatorFunc, error) {
+	name, args, _ := strings.Cut(spec, ":")
+
+	factory, ok := r.validators[name]
 	if !ok {
 		return nil, errors.New("validator '" + name + "' does not exists")
 	}
-	return v, nil
+	return factory(args)
 }
 
 func (r *router) exportRoutes() []Route {
-	return r.routes
+	if len(r.hosts) == 0 && len(r.mounts) == 0 {
+		return r.routes
+	}
+
+	var routes []Route
+	for _, route := range r.routes {
+		// mounted apps are exported with their own routes
+		if i := slices.IndexFunc(r.mounts, func(m *mountedApp) bool { return m.route == route }); i != -1 {
+			m := r.mounts[i]
+			for _, sub := range m.app.exportRoutes() {
+				routes = append(routes, &mountedRoute{Route: sub, prefix: m.prefix})
+			}
+			continue
+		}
+		routes = append(routes, route)
+	}
+
+	for _, h := range r.hosts {
+		routes = append(routes, h.exportRoutes()...)
+	}
+	return routes
+}
+
+// match finds the route for the host, method and path.
+// Host routers with a matching host pattern are tried first, then the default routes.
+func (r *router) match(host, method, path string) (Route, map[string]string, map[string]any) {
+	for _, h := range r.hosts {
+		hostParams, hostTyped, ok := matchHost(h.hostParts, host)
+		if !ok {
+			continue
+		}
+
+		if route, params, typed := h.matchPath(method, path); route != nil {
+			maps.Copy(params, hostParams)
+			maps.Copy(typed, hostTyped)
+			return route, params, typed
+		}
+	}
+
+	return r.matchPath(method, path)
+}
+
+// matchPath finds the route for the method and path.
+// The routes of the method and the ones registered with All, like mounts, are ranked together by priority,
+// on the same priority the route registered for the method wins.
+func (r *router) matchPath(method, path string) (Route, map[string]string, map[string]any) {
+	leaf, values := lookupTree(r.trees[method], path)
+
+	if all, allValues := lookupTree(r.trees["*"], path); all != nil && (leaf == nil || moreSpecific(allValues, values)) {
+		leaf, values = all, allValues
+	}
+
+	if leaf == nil {
+		return nil, nil, nil
+	}
+	return leaf.route, leafParams(leaf, values), leafTyped(leaf, values)
+}
+
+// allowedMethods returns the methods that have a route for the host and path,
+// HEAD and OPTIONS are included because they are answered automatically.
+func (r *router) allowedMethods(host, path string) []string {
+	methods := r.pathMethods(path)
+
+	for _, h := range r.hosts {
+		if _, _, ok := matchHost(h.hostParts, host); !ok {
+			continue
+		}
+
+		for _, method := range h.pathMethods(path) {
+			if !slices.Contains(methods, method) {
+				methods = append(methods, method)
+			}
+		}
+	}
+
+	if len(methods) == 0 {
+		return nil
+	}
+
+	if slices.Contains(methods, http.MethodGet) && !slices.Contains(methods, http.MethodHead) {
+		methods = append(methods, http.MethodHead)
+	}
+
+	if !slices.Contains(methods, http.MethodOptions) {
+		methods = append(methods, http.MethodOptions)
+	}
+
+	slices.Sort(methods)
+	return methods
+}
+
+func (r *router) pathMethods(path string) []string {
+	var methods []string
+
+	for method, tree := range r.trees {
+		if method == "*" {
+			continue
+		}
+
+		if route, _ := matchTree(tree, path); route != nil {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods
 }
 
 type routerGroup struct {
@@ -203,6 +582,10 @@ func (r *routerGroup) WS(path string, handler WSHandlerFunc, middlewares ...Midd
 	return r.Add(http.MethodGet, path, wsHandler(handler), middlewares...)
 }
 
+func (r *routerGroup) Mount(prefix string, h http.Handler, middlewares ...MiddlewareFunc) Route {
+	return r.router.Mount(path.Join(r.prefix, prefix), h, append(r.middlewares, middlewares...)...)
+}
+
 func (r *routerGroup) Group(prefix string, middlewares ...MiddlewareFunc) Router {
 	return &routerGroup{
 		router:      r.router,
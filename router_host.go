@@ -0,0 +1,104 @@
+package gale
+
+import (
+	"net"
+	"strings"
+)
+
+// parseHost parses a host pattern like "{tenant}.example.com" into its labels.
+// Static labels are lowercased, param names and validators are kept as written.
+func parseHost(pattern string) []RoutePart {
+	var parts []RoutePart
+
+	for _, label := range splitHost(pattern) {
+		if !strings.HasPrefix(label, "{") || !strings.HasSuffix(label, "}") {
+			parts = append(parts, RoutePart{Static: true, Value: strings.ToLower(label)})
+			continue
+		}
+
+		label = label[1 : len(label)-1]
+		name, validators, _ := strings.Cut(label, "@")
+
+		part := RoutePart{Value: name}
+		if validators != "" {
+			part.Validators = splitValidators(validators)
+		}
+		parts = append(parts, part)
+	}
+
+	return parts
+}
+
+// splitHost splits a host pattern by the dots outside of the params, like the ones of a regex validator
+func splitHost(pattern string) []string {
+	var (
+		labels []string
+		depth  int
+		start  int
+	)
+
+	for i, r := range pattern {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '.':
+			if depth == 0 {
+				labels = append(labels, pattern[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(labels, pattern[start:])
+}
+
+func hostHasParams(parts []RoutePart) bool {
+	for _, part := range parts {
+		if !part.Static {
+			return true
+		}
+	}
+	return false
+}
+
+// matchHost compares the request host with the host pattern and returns the host params,
+// with the values converted by the built-in validators
+func matchHost(parts []RoutePart, host string) (map[string]string, map[string]any, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	labels := strings.Split(strings.ToLower(host), ".")
+	if len(labels) != len(parts) {
+		return nil, nil, false
+	}
+
+	params := make(map[string]string)
+	typed := make(map[string]any)
+	for i, part := range parts {
+		if part.Static {
+			if part.Value != labels[i] {
+				return nil, nil, false
+			}
+			continue
+		}
+
+		if labels[i] == "" {
+			return nil, nil, false
+		}
+
+		value, converted, err := runValidators(labels[i], part.validators, part.converters)
+		if err != nil {
+			return nil, nil, false
+		}
+
+		params[part.Value] = value
+		if converted != nil {
+			typed[part.Value] = converted
+		}
+	}
+
+	return params, typed, true
+}
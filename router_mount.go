@@ -0,0 +1,77 @@
+package gale
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// mountedApp is a sub application mounted under a prefix
+type mountedApp struct {
+	route  Route
+	prefix string
+	app    *Gale
+}
+
+// mountHandler serves the request with the handler after removing the prefix from the path
+func mountHandler(prefix string, h http.Handler) HandlerFunc {
+	return func(c Ctx) error {
+		r := c.Request()
+
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = stripPrefix(r.URL.Path, prefix)
+		if r.URL.RawPath != "" {
+			r2.URL.RawPath = stripPrefix(r.URL.RawPath, prefix)
+		}
+
+		h.ServeHTTP(c.ResponseWriter(), r2)
+		return nil
+	}
+}
+
+func stripPrefix(p, prefix string) string {
+	p = strings.TrimPrefix(p, prefix)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
+
+// mountedRoute is a route of a mounted application exported with the mount prefix
+type mountedRoute struct {
+	Route
+	prefix string
+}
+
+func (r *mountedRoute) Path() string {
+	return path.Join(r.prefix, r.Route.Path())
+}
+
+func (r *mountedRoute) NormalizedPaths() []string {
+	paths := r.Route.NormalizedPaths()
+	for i, p := range paths {
+		paths[i] = path.Join(r.prefix, p)
+	}
+	return paths
+}
+
+func (r *mountedRoute) allRoutesParts() [][]RoutePart {
+	var prefix []RoutePart
+	for _, segment := range strings.Split(strings.Trim(r.prefix, "/"), "/") {
+		prefix = append(prefix, RoutePart{Static: true, Value: segment})
+	}
+
+	var all [][]RoutePart
+	for _, parts := range r.Route.allRoutesParts() {
+		if len(parts) == 1 && parts[0].Static && parts[0].Value == "" {
+			all = append(all, prefix)
+			continue
+		}
+		all = append(all, append(append([]RoutePart{}, prefix...), parts...))
+	}
+	return all
+}
@@ -0,0 +1,424 @@
+package gale
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterMatch(t *testing.T) {
+	app := New()
+
+	app.Get("/", func(c Ctx) error { return c.SendString("index") })
+	app.Get("/users", func(c Ctx) error { return c.SendString("users") })
+	app.Get("/users/{id@int}", func(c Ctx) error { return c.SendString("user " + c.Param("id")) })
+	app.Get("/users/{id@int}/posts/{post}", func(c Ctx) error {
+		return c.SendString("post " + c.Param("id") + " " + c.Param("post"))
+	})
+	app.Get("/user/{name}?", func(c Ctx) error { return c.SendString("name " + c.Param("name", "unknown")) })
+	app.All("/any", func(c Ctx) error { return c.SendString("any " + c.Method()) })
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{http.MethodGet, "/", http.StatusOK, "index"},
+		{http.MethodGet, "/users", http.StatusOK, "users"},
+		{http.MethodGet, "/users/", http.StatusOK, "users"},
+		{http.MethodGet, "/users/12", http.StatusOK, "user 12"},
+		{http.MethodGet, "/users/abc", http.StatusNotFound, ""},
+		{http.MethodGet, "/users/12/posts/hello", http.StatusOK, "post 12 hello"},
+		{http.MethodGet, "/user", http.StatusOK, "name unknown"},
+		{http.MethodGet, "/user/john", http.StatusOK, "name john"},
+		{http.MethodPost, "/any", http.StatusOK, "any POST"},
+		{http.MethodGet, "/usersx", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.server.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+		assert.Equal(t, tt.status, w.Code, tt.method+" "+tt.path)
+		if tt.body != "" {
+			assert.Equal(t, tt.body, w.Body.String(), tt.method+" "+tt.path)
+		}
+	}
+}
+
+func TestRouterPriority(t *testing.T) {
+	app := New()
+
+	app.Get("/users/{name}", func(c Ctx) error { return c.SendString("name " + c.Param("name")) })
+	app.Get("/users/{id@int}", func(c Ctx) error { return c.SendString("id " + c.Param("id")) })
+	app.Get("/users/me", func(c Ctx) error { return c.SendString("me") })
+	app.All("/users/new", func(c Ctx) error { return c.SendString("all new") })
+	app.All("/users/{id@int}/posts", func(c Ctx) error { return c.SendString("all posts") })
+	app.Get("/users/{name}/{tab}", func(c Ctx) error { return c.SendString("tab " + c.Param("tab")) })
+	app.All("/files/{path...}", func(c Ctx) error { return c.SendString("all files") })
+	app.Get("/files/{path...}", func(c Ctx) error { return c.SendString("get files") })
+
+	tests := map[string]string{
+		"/users/me":       "me",
+		"/users/12":       "id 12",
+		"/users/john":     "name john",
+		"/users/new":      "all new",
+		"/users/12/posts": "all posts",
+		"/users/x/posts":  "tab posts",
+		"/files/a/b":      "get files",
+	}
+
+	for path, body := range tests {
+		w := httptest.NewRecorder()
+		app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, body, w.Body.String(), path)
+	}
+}
+
+func TestRouterShadowed(t *testing.T) {
+	tree := newTree()
+
+	first := newRoute(http.MethodGet, "/users/{id}", nil, nil)
+	assert.Empty(t, tree.insert(first))
+
+	shadowed := tree.insert(newRoute(http.MethodGet, "/users/{name}", nil, nil))
+	if assert.Len(t, shadowed, 1) {
+		assert.Equal(t, "/users/{name}", shadowed[0].path)
+		assert.Equal(t, first, shadowed[0].by)
+	}
+
+	assert.Empty(t, tree.insert(newRoute(http.MethodGet, "/users/{id@int}", nil, nil)))
+
+	shadowed = tree.insert(newRoute(http.MethodGet, "/users/{id}/{tab}?", nil, nil))
+	if assert.Len(t, shadowed, 1) {
+		assert.Equal(t, "/users/{id}", shadowed[0].path)
+	}
+
+	var logs bytes.Buffer
+	app := New(&Config{Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+	app.Get("/posts/{id}", func(c Ctx) error { return nil })
+	app.Get("/posts/{slug}", func(c Ctx) error { return nil })
+	assert.Contains(t, logs.String(), "level=WARN")
+	assert.Contains(t, logs.String(), `route="GET /posts/{slug}" shadowed_by="GET /posts/{id}"`)
+}
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	app := New()
+
+	app.Get("/items", func(c Ctx) error { return c.SendString("items") })
+	app.Post("/items", func(c Ctx) error { return c.SendString("created") })
+
+	w := httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/items", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", w.Header().Get("Allow"))
+
+	w = httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/items", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", w.Header().Get("Allow"))
+	assert.Empty(t, w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Length"))
+
+	srv := httptest.NewServer(app.Handler())
+	defer srv.Close()
+
+	res, err := http.Head(srv.URL + "/items")
+	if assert.Nil(t, err) {
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, int64(5), res.ContentLength)
+		assert.Empty(t, body)
+	}
+
+	w = httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRouterCatchAll(t *testing.T) {
+	app := New()
+
+	app.Get("/files/{path...}", func(c Ctx) error { return c.SendString("file " + c.Param("path")) })
+	app.Get("/files/readme", func(c Ctx) error { return c.SendString("readme") })
+	app.Get("/docs/*", func(c Ctx) error { return c.SendString("docs " + c.Param("*")) })
+	app.Get("/num/{rest...@int}", func(c Ctx) error { return c.SendString("num " + c.Param("rest")) })
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/files/a/b/c.txt", http.StatusOK, "file a/b/c.txt"},
+		{"/files/readme", http.StatusOK, "readme"},
+		{"/files/readme/more", http.StatusOK, "file readme/more"},
+		{"/files", http.StatusNotFound, ""},
+		{"/docs/intro/setup", http.StatusOK, "docs intro/setup"},
+		{"/num/12", http.StatusOK, "num 12"},
+		{"/num/12/13", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		assert.Equal(t, tt.status, w.Code, tt.path)
+		if tt.body != "" {
+			assert.Equal(t, tt.body, w.Body.String(), tt.path)
+		}
+	}
+
+	assert.Equal(t, []string{"/files/{path...}"}, newRoute(http.MethodGet, "/files/{path...}", nil, nil).NormalizedPaths())
+	assert.Equal(t, []string{"/docs/*"}, newRoute(http.MethodGet, "/docs/*", nil, nil).NormalizedPaths())
+}
+
+func TestRouterURL(t *testing.T) {
+	app := New()
+
+	app.Get("/users/{id@int}/posts/{tab}?", nil).Name("user.posts")
+	app.Get("/files/{path...}", nil).Name("files")
+
+	u, err := app.URL("user.posts", Map{"id": 12, "tab": "latest"})
+	assert.Nil(t, err)
+	assert.Equal(t, "/users/12/posts/latest", u)
+
+	u, err = app.URL("user.posts", Map{"id": 12, "page": 2})
+	assert.Nil(t, err)
+	assert.Equal(t, "/users/12/posts?page=2", u)
+
+	u, err = app.URL("files", Map{"path": "a b/c.txt"})
+	assert.Nil(t, err)
+	assert.Equal(t, "/files/a%20b/c.txt", u)
+
+	_, err = app.URL("user.posts", Map{"id": "abc"})
+	assert.Error(t, err)
+
+	_, err = app.URL("user.posts", nil)
+	assert.Error(t, err)
+
+	_, err = app.URL("missing", nil)
+	assert.Error(t, err)
+
+	app.Get("/go", func(c Ctx) error {
+		return c.RedirectRoute("files", Map{"path": "x"})
+	})
+
+	w := httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/go", nil))
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.Equal(t, "/files/x", w.Header().Get("Location"))
+}
+
+func TestRouterHost(t *testing.T) {
+	app := New()
+
+	app.Host("api.example.com").Get("/", func(c Ctx) error { return c.SendString("api") })
+	app.Host("{tenant@alpha}.example.com").Get("/", func(c Ctx) error { return c.SendString("tenant " + c.Param("tenant")) })
+	app.Host("{regionId@regex:^[a-z]+\\.?[0-9]$}.API.Example.com").Get("/", func(c Ctx) error { return c.SendString("region " + c.Param("regionId")) })
+	app.Get("/", func(c Ctx) error { return c.SendString("default") })
+	app.Get("/health", func(c Ctx) error { return c.SendString("ok") })
+
+	tests := map[string]string{
+		"api.example.com":       "api",
+		"acme.example.com:8080": "tenant acme",
+		"acme1.example.com":     "default",
+		"eu1.api.example.com":   "region eu1",
+		"EU2.Api.Example.com":   "region eu2",
+		"localhost":             "default",
+	}
+
+	for host, body := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Host = host
+
+		w := httptest.NewRecorder()
+		app.server.ServeHTTP(w, r)
+		assert.Equal(t, body, w.Body.String(), host)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/health", nil)
+	r.Host = "acme.example.com"
+
+	w := httptest.NewRecorder()
+	app.server.ServeHTTP(w, r)
+	assert.Equal(t, "ok", w.Body.String())
+
+	assert.Len(t, app.Export(), 5)
+}
+
+func TestRouterMount(t *testing.T) {
+	app := New()
+
+	app.Mount("/metrics", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("metrics " + r.URL.Path))
+	}))
+
+	sub := New(&Config{
+		NotFoundHandler: func(c Ctx) error {
+			return c.Status(http.StatusNotFound).SendString("sub not found")
+		},
+	})
+	sub.Get("/", func(c Ctx) error { return c.SendString("sub index") })
+	sub.Get("/users/{id}", func(c Ctx) error { return c.SendString("sub user " + c.Param("id")) }).Name("sub.user")
+
+	app.MountApp("/sub", sub)
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/metrics", http.StatusOK, "metrics /"},
+		{"/metrics/go/gc", http.StatusOK, "metrics /go/gc"},
+		{"/sub", http.StatusOK, "sub index"},
+		{"/sub/users/12", http.StatusOK, "sub user 12"},
+		{"/sub/missing", http.StatusNotFound, "sub not found"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		assert.Equal(t, tt.status, w.Code, tt.path)
+		assert.Equal(t, tt.body, w.Body.String(), tt.path)
+	}
+
+	var paths []string
+	for _, route := range app.Export() {
+		paths = append(paths, route.NormalizedPaths()...)
+	}
+	assert.Contains(t, paths, "/sub")
+	assert.Contains(t, paths, "/sub/users/{id}")
+
+	u, err := app.URL("sub.user", Map{"id": 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "/sub/users/1", u)
+
+	spa := New()
+	spa.Get("/{path...}", func(c Ctx) error { return c.SendString("spa " + c.Param("path")) })
+	spa.MountApp("/admin", sub)
+
+	for path, body := range map[string]string{
+		"/admin":          "sub index",
+		"/admin/users/12": "sub user 12",
+		"/dashboard/home": "spa dashboard/home",
+	} {
+		w := httptest.NewRecorder()
+		spa.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, body, w.Body.String(), path)
+	}
+	assert.Nil(t, spa.Validate())
+}
+
+func TestRouterValidate(t *testing.T) {
+	app := New()
+
+	app.Get("/users/{id@int}", func(c Ctx) error { return c.SendString("user") })
+	assert.Nil(t, app.Validate())
+
+	app.Get("/users/{id@int}", nil)
+	app.Get("/posts/{id@missing}", nil)
+	app.Get("/files/{path...}/raw", nil)
+	app.Host("{tenant@missing}.example.com")
+	app.RegisterRouteParamValidator("int", validateInt)
+	app.Hook(PreRequestHook)
+
+	sub := New()
+	sub.Get("/{id@}", nil)
+	app.MountApp("/sub", sub)
+
+	err := app.Validate()
+	if assert.Error(t, err) {
+		var routeErr *RouteError
+		assert.ErrorAs(t, err, &routeErr)
+		assert.Contains(t, err.Error(), `route "GET /users/{id@int}": route already exists`)
+		assert.Contains(t, err.Error(), `validator 'missing' does not exists`)
+		assert.Contains(t, err.Error(), "catch-all parameters must be the last segment")
+		assert.Contains(t, err.Error(), `host "{tenant@missing}.example.com"`)
+		assert.Contains(t, err.Error(), `route param validator "int" already exists`)
+		assert.Contains(t, err.Error(), "no functions provided for hook")
+		assert.Contains(t, err.Error(), "missing validator name")
+	}
+
+	// the first route is still served
+	w := httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	assert.Equal(t, "user", w.Body.String())
+
+	ln, lnErr := net.Listen("tcp", "127.0.0.1:0")
+	if assert.Nil(t, lnErr) {
+		assert.Error(t, app.Listener(ln))
+	}
+
+	noSession := New(&Config{Session: &SessionConfig{Enabled: false}})
+	c := noSession.NewTestContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.ErrorIs(t, c.Session().Set("key", nil), ErrSessionsDisabled)
+}
+
+func TestRouterValidatorArgs(t *testing.T) {
+	app := New()
+
+	app.RegisterRouteParamValidatorFactory("suffix", func(args string) (RouteParamValidatorFunc, error) {
+		return func(value string) (string, error) {
+			if !strings.HasSuffix(value, args) {
+				return "", errors.New("missing suffix")
+			}
+			return strings.TrimSuffix(value, args), nil
+		}, nil
+	})
+
+	app.Get("/users/{id@int,min:1,max:1000}", func(c Ctx) error { return c.SendString("user " + c.Param("id")) })
+	app.Get("/posts/{slug@len:3-8}", func(c Ctx) error { return c.SendString("post " + c.Param("slug")) })
+	app.Get("/list/{sort@in:asc|desc}", func(c Ctx) error { return c.SendString("sort " + c.Param("sort")) })
+	app.Get("/codes/{code@regex:^[A-Z]{2,3}$}", func(c Ctx) error { return c.SendString("code " + c.Param("code")) })
+	app.Get("/images/{image@suffix:.webp}", func(c Ctx) error { return c.SendString("image " + c.Param("image")) })
+	assert.Nil(t, app.Validate())
+
+	tests := map[string]string{
+		"/users/1":         "user 1",
+		"/users/1000":      "user 1000",
+		"/users/0":         "",
+		"/users/1001":      "",
+		"/posts/abc":       "post abc",
+		"/posts/ab":        "",
+		"/posts/abcdefghi": "",
+		"/list/desc":       "sort desc",
+		"/list/random":     "",
+		"/codes/HUF":       "code HUF",
+		"/codes/huf":       "",
+		"/images/cat.webp": "image cat",
+		"/images/cat.png":  "",
+	}
+
+	for path, body := range tests {
+		w := httptest.NewRecorder()
+		app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if body == "" {
+			assert.Equal(t, http.StatusNotFound, w.Code, path)
+			continue
+		}
+		assert.Equal(t, body, w.Body.String(), path)
+	}
+
+	app.Get("/bad/{id@min:x}", nil)
+	app.Get("/bad/{id@int:1}", nil)
+	app.Get("/bad/{id@regex:[}", nil)
+
+	err := app.Validate()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid min argument 'x'")
+		assert.Contains(t, err.Error(), "validator 'int' does not accept arguments")
+		assert.Contains(t, err.Error(), "invalid regex argument")
+	}
+}
@@ -0,0 +1,303 @@
+package gale
+
+import (
+	"slices"
+	"strings"
+)
+
+// nodeKind is the type of a route tree node
+type nodeKind uint8
+
+const (
+	// nodeStatic matches a fixed part of the path
+	nodeStatic nodeKind = iota
+	// nodeValidated matches a single path segment accepted by the param validators
+	nodeValidated
+	// nodeParam matches any single path segment
+	nodeParam
+	// nodeCatchAll matches the rest of the path
+	nodeCatchAll
+)
+
+// node is a node of the compressed prefix tree used for route matching.
+// Static nodes can span multiple path segments, parameter nodes match a single segment
+// and catch-all nodes match everything until the end of the path.
+// Parameter nodes are shared by the routes with the same validators, the param names are stored on the leaf.
+type node struct {
+	kind       nodeKind
+	prefix     string
+	validators []string
+	fns        []RouteParamValidatorFunc
+	converters []paramConverter
+
+	children []*node
+	params   []*node
+
+	route Route
+	names []string
+}
+
+// shadowedPath is a route path that can never be matched because an earlier route has the same pattern
+type shadowedPath struct {
+	path string
+	by   Route
+}
+
+func newTree() *node {
+	return &node{kind: nodeStatic}
+}
+
+// routeTokens merges the static parts of a route into path prefixes
+// so that "/users/{id}/posts" becomes "/users/", {id} and "/posts".
+func routeTokens(parts []RoutePart) []RoutePart {
+	var (
+		tokens []RoutePart
+		static strings.Builder
+	)
+
+	for _, part := range parts {
+		static.WriteString("/")
+		if part.Static {
+			static.WriteString(part.Value)
+			continue
+		}
+
+		tokens = append(tokens, RoutePart{Static: true, Value: static.String()}, part)
+		static.Reset()
+	}
+
+	if static.Len() > 0 {
+		tokens = append(tokens, RoutePart{Static: true, Value: static.String()})
+	}
+
+	return tokens
+}
+
+// insert adds every path of the route to the tree and returns the paths shadowed by earlier routes
+func (n *node) insert(route Route) []shadowedPath {
+	var shadowed []shadowedPath
+
+	for _, parts := range route.allRoutesParts() {
+		if by := n.insertTokens(routeTokens(parts), paramNames(parts), route); by != nil {
+			shadowed = append(shadowed, shadowedPath{path: normalizePath(parts), by: by})
+		}
+	}
+
+	return shadowed
+}
+
+// insertTokens inserts the tokens below the node, if the pattern is already taken it returns the owner route
+func (n *node) insertTokens(tokens []RoutePart, names []string, route Route) Route {
+	if len(tokens) == 0 {
+		if n.route != nil {
+			return n.route
+		}
+
+		n.route = route
+		n.names = names
+		return nil
+	}
+
+	token := tokens[0]
+	if token.Static {
+		return n.insertStatic(token.Value, tokens[1:], names, route)
+	}
+
+	kind := nodeParam
+	switch {
+	case token.CatchAll:
+		kind = nodeCatchAll
+	case len(token.Validators) > 0:
+		kind = nodeValidated
+	}
+
+	for _, child := range n.params {
+		if child.kind == kind && slices.Equal(child.validators, token.Validators) {
+			return child.insertTokens(tokens[1:], names, route)
+		}
+	}
+
+	child := &node{kind: kind, validators: token.Validators, fns: token.validators, converters: token.converters}
+
+	// keep the params ordered by priority: validated params, plain params, then catch-alls
+	i := len(n.params)
+	for i > 0 && n.params[i-1].kind > child.kind {
+		i--
+	}
+	n.params = slices.Insert(n.params, i, child)
+
+	return child.insertTokens(tokens[1:], names, route)
+}
+
+func (n *node) insertStatic(prefix string, tokens []RoutePart, names []string, route Route) Route {
+	if prefix == "" {
+		return n.insertTokens(tokens, names, route)
+	}
+
+	for i, child := range n.children {
+		l := commonPrefix(child.prefix, prefix)
+		if l == 0 {
+			continue
+		}
+
+		if l < len(child.prefix) {
+			split := &node{
+				kind:     nodeStatic,
+				prefix:   child.prefix[:l],
+				children: []*node{child},
+			}
+			child.prefix = child.prefix[l:]
+			n.children[i] = split
+			child = split
+		}
+
+		return child.insertStatic(prefix[l:], tokens, names, route)
+	}
+
+	child := &node{kind: nodeStatic, prefix: prefix}
+	n.children = append(n.children, child)
+	return child.insertTokens(tokens, names, route)
+}
+
+// paramMatch is a param value found by lookup with its position and kind, used to rank matches of different trees
+type paramMatch struct {
+	value string
+	// rest is the length of the path left at the start of the param, earlier params have a longer rest
+	rest int
+	kind nodeKind
+	// typed is the value converted by a built-in validator, like the int of {id@int}
+	typed any
+}
+
+// moreSpecific reports whether the match a has a higher priority than b, by the same rules as the tree lookup:
+// at the first position where they differ, static beats params, and validated params beat plain params and catch-alls.
+func moreSpecific(a, b []paramMatch) bool {
+	for i := 0; ; i++ {
+		switch {
+		case i == len(a):
+			return i < len(b)
+		case i == len(b):
+			return false
+		case a[i].rest != b[i].rest:
+			return a[i].rest < b[i].rest
+		case a[i].kind != b[i].kind:
+			return a[i].kind < b[i].kind
+		}
+	}
+}
+
+// lookup finds the leaf matching the path and collects the param values.
+// Children are tried by priority: static, validated params, plain params, then catch-alls.
+func (n *node) lookup(path string, values *[]paramMatch) *node {
+	switch n.kind {
+	case nodeStatic:
+		if !strings.HasPrefix(path, n.prefix) {
+			return nil
+		}
+		path = path[len(n.prefix):]
+	default:
+		end := strings.IndexByte(path, '/')
+		if end == -1 || n.kind == nodeCatchAll {
+			end = len(path)
+		}
+
+		if end == 0 {
+			return nil
+		}
+
+		value, typed, err := runValidators(path[:end], n.fns, n.converters)
+		if err != nil {
+			return nil
+		}
+
+		*values = append(*values, paramMatch{value: value, rest: len(path), kind: n.kind, typed: typed})
+		path = path[end:]
+	}
+
+	if path == "" && n.route != nil {
+		return n
+	}
+
+	if path != "" {
+		for _, child := range n.children {
+			if child.prefix[0] != path[0] {
+				continue
+			}
+
+			if leaf := child.lookup(path, values); leaf != nil {
+				return leaf
+			}
+			break
+		}
+
+		for _, child := range n.params {
+			if leaf := child.lookup(path, values); leaf != nil {
+				return leaf
+			}
+		}
+	}
+
+	if n.kind != nodeStatic {
+		*values = (*values)[:len(*values)-1]
+	}
+
+	return nil
+}
+
+// matchTree looks up the path in the tree and returns the route with its params
+func matchTree(tree *node, path string) (Route, map[string]string) {
+	leaf, values := lookupTree(tree, path)
+	if leaf == nil {
+		return nil, nil
+	}
+	return leaf.route, leafParams(leaf, values)
+}
+
+// lookupTree returns the leaf matching the path with the matched params
+func lookupTree(tree *node, path string) (*node, []paramMatch) {
+	if tree == nil {
+		return nil, nil
+	}
+
+	var values []paramMatch
+	leaf := tree.lookup("/"+strings.Trim(path, "/"), &values)
+	return leaf, values
+}
+
+// leafParams names the matched param values by the params of the leaf route
+func leafParams(leaf *node, values []paramMatch) map[string]string {
+	params := make(map[string]string, len(values))
+	for i, name := range leaf.names {
+		params[name] = values[i].value
+	}
+	return params
+}
+
+// leafTyped names the param values converted by the built-in validators
+func leafTyped(leaf *node, values []paramMatch) map[string]any {
+	typed := make(map[string]any)
+	for i, name := range leaf.names {
+		if values[i].typed != nil {
+			typed[name] = values[i].typed
+		}
+	}
+	return typed
+}
+
+func paramNames(parts []RoutePart) []string {
+	var names []string
+	for _, part := range parts {
+		if !part.Static {
+			names = append(names, part.Value)
+		}
+	}
+	return names
+}
+
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
@@ -1,9 +1,11 @@
 package gale
 
 import (
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -14,13 +16,26 @@ type server struct {
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 
-	// log the current request information in development mode
-	defer func(start time.Time, method string, path string, dev bool) {
-		if !dev {
-			return
-		}
-		serverLogger(start, method, path)
-	}(start, r.Method, r.URL.Path, s.app.config.Mode == Development)
+	s.app.inflight.Add(1)
+	defer s.app.inflight.Done()
+
+	r, id := s.app.withRequestID(w, r)
+
+	defer func(start time.Time, method string, path string) {
+		s.app.config.Logger.LogAttrs(r.Context(), slog.LevelInfo, "request",
+			slog.String("request_id", id),
+			slog.String("method", method),
+			slog.String("path", path),
+			slog.Duration("duration", time.Since(start)),
+		)
+	}(start, r.Method, r.URL.Path)
+
+	s.serve(w, r)
+}
+
+// serve handles the request without logging, mounted applications are served with it
+func (s *server) serve(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
 
 	if s.app.publicDir != "" {
 		if stat, err := os.Stat(filepath.Join(s.app.publicDir, r.URL.Path)); err == nil && !stat.IsDir() {
@@ -29,38 +44,46 @@ func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	for _, route := range s.app.exportRoutes() {
-		method := route.Method()
-		if method == r.Method || method == "*" {
-			ok, params := route.comparePath(s.app.CompleteRouter, r.URL.Path)
-			if !ok {
-				continue
-			}
+	route, params, typed := s.app.match(r.Host, r.Method, r.URL.Path)
 
-			ctx := newCtx(s.app, route, w, r, params)
+	// serve HEAD requests from the GET handler, net/http drops the body but keeps the Content-Length
+	if route == nil && r.Method == http.MethodHead {
+		route, params, typed = s.app.match(r.Host, http.MethodGet, r.URL.Path)
+	}
 
-			for _, hook := range s.app.hooks[EveryRequestHook] {
-				err := hook(ctx)
-				if err != nil {
-					handleError(ctx, s.app.config.ErrorHandler(ctx, err))
-				}
+	if route != nil {
+		s.limitBody(route, w, r)
 
-				if !ctx.canContinue() {
-					return
-				}
+		ctx := newCtx(s.app, route, w, r, params, typed)
+		defer s.app.logAccess(ctx, start)
+		defer ctx.finished()
+		defer s.commit(ctx)
+		defer s.recover(ctx)
+
+		for _, hook := range s.app.hooks[EveryRequestHook] {
+			err := hook(ctx)
+			if err != nil {
+				s.sendError(ctx, err)
 			}
 
-			s.handleRoute(route, ctx)
-			return
+			if !ctx.canContinue() {
+				return
+			}
 		}
+
+		s.handleRoute(ctx)
+		return
 	}
 
-	ctx := newCtx(s.app, nil, w, r, nil)
+	ctx := newCtx(s.app, nil, w, r, nil, nil)
+	defer s.app.logAccess(ctx, start)
+	defer s.commit(ctx)
+	defer s.recover(ctx)
 
 	for _, hook := range s.app.hooks[EveryRequestHook] {
 		err := hook(ctx)
 		if err != nil {
-			handleError(ctx, s.app.config.ErrorHandler(ctx, err))
+			s.sendError(ctx, err)
 		}
 
 		if !ctx.canContinue() {
@@ -68,17 +91,33 @@ func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if allowed := s.app.allowedMethods(r.Host, r.URL.Path); len(allowed) > 0 {
+		ctx.Header().Add("Allow", strings.Join(allowed, ", "))
+
+		if r.Method == http.MethodOptions {
+			// a 204 response has no body
+			ctx.Status(http.StatusNoContent).writeHeaders()
+			return
+		}
+
+		err := s.app.config.MethodNotAllowedHandler(ctx)
+		if err != nil {
+			s.sendError(ctx, err)
+		}
+		return
+	}
+
 	err := s.app.config.NotFoundHandler(ctx)
 	if err != nil {
-		handleError(ctx, s.app.config.ErrorHandler(ctx, err))
+		s.sendError(ctx, err)
 	}
 }
 
-func (s *server) handleRoute(route Route, ctx Ctx) {
+func (s *server) handleRoute(ctx Ctx) {
 	for _, hook := range s.app.hooks[PreRequestHook] {
 		err := hook(ctx)
 		if err != nil {
-			handleError(ctx, s.app.config.ErrorHandler(ctx, err))
+			s.sendError(ctx, err)
 			return
 		}
 
@@ -96,30 +135,40 @@ func (s *server) handleRoute(route Route, ctx Ctx) {
 		}
 	}(s, ctx)
 
-	for _, m := range route.Middlewares() {
-		err := m(ctx)
-		if err != nil {
-			handleError(ctx, s.app.config.ErrorHandler(ctx, err))
-			return
-		}
+	// runs the middlewares and the handler, middlewares can wrap the rest of the chain with ctx.Next()
+	if err := ctx.Next(); err != nil {
+		s.sendError(ctx, err)
+	}
+}
 
-		if !ctx.canContinue() {
-			return
-		}
+// limitBody caps the request body by the limit of the route or the application
+func (s *server) limitBody(route Route, w http.ResponseWriter, r *http.Request) {
+	limit := route.GetBodyLimit()
+	if limit == 0 {
+		limit = s.app.config.BodyLimit
 	}
 
-	if !ctx.canContinue() {
-		return
+	if limit > 0 && r.Body != nil && r.Body != http.NoBody {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
 	}
+}
 
-	err := route.Handler()(ctx)
-	if err != nil {
-		handleError(ctx, s.app.config.ErrorHandler(ctx, err))
+// commit writes the buffered response after the request chain and the hooks are finished
+func (s *server) commit(ctx Ctx) {
+	if err := ctx.Response().Commit(); err != nil {
+		s.app.runErrorHooks(ctx, err)
 	}
 }
 
+// sendError reports the error to the OnError hooks and sends it with the error handler
+func (s *server) sendError(ctx Ctx, err error) {
+	s.app.runErrorHooks(ctx, err)
+	handleError(ctx, s.app.config.ErrorHandler(ctx, err))
+}
+
 func handleError(ctx Ctx, err error) {
 	if err != nil {
+		ctx.Logger().Error("failed to handle error", "error", err)
 		http.Error(ctx.ResponseWriter(), err.Error(), 500)
 	}
 }
@@ -0,0 +1,289 @@
+package gale
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareNext(t *testing.T) {
+	app := New()
+
+	var events []string
+	around := func(c Ctx) error {
+		events = append(events, "before")
+		err := c.Next()
+		events = append(events, "after")
+
+		// the middleware can replace the error of the handler
+		if errors.Is(err, errTestHandler) {
+			return NewError(http.StatusConflict, "conflict")
+		}
+		return err
+	}
+	plain := func(c Ctx) error {
+		events = append(events, "plain")
+		return nil
+	}
+
+	app.Get("/", func(c Ctx) error {
+		events = append(events, "handler")
+		return errTestHandler
+	}, around, plain)
+
+	app.Get("/break", func(c Ctx) error {
+		events = append(events, "unreachable")
+		return nil
+	}, around, func(c Ctx) error {
+		return c.Break().Status(http.StatusUnauthorized).SendString("Unauthorized")
+	})
+
+	w := httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, []string{"before", "plain", "handler", "after"}, events)
+
+	events = nil
+	w = httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/break", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, []string{"before", "after"}, events)
+}
+
+var errTestHandler = errors.New("handler error")
+
+func TestResponseCapture(t *testing.T) {
+	app := New()
+
+	var status int
+	var size int64
+	app.Hook(PostRequestHook, func(c Ctx) error {
+		status, size = c.Response().Status(), c.Response().Size()
+		return nil
+	})
+
+	app.Get("/plain", func(c Ctx) error {
+		return c.Status(http.StatusCreated).SendString("hello")
+	})
+
+	app.Get("/upper", func(c Ctx) error {
+		return c.SendString("hello")
+	}, func(c Ctx) error {
+		c.Response().Buffer()
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		c.Response().Header().Set("X-Rewritten", "true")
+		c.Response().SetStatus(http.StatusAccepted)
+		c.Response().SetBody([]byte("HELLO WORLD"))
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/plain", nil))
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, int64(5), size)
+	assert.Equal(t, "hello", w.Body.String())
+
+	w = httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/upper", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "true", w.Header().Get("X-Rewritten"))
+	assert.Equal(t, "11", w.Header().Get("Content-Length"))
+	assert.Equal(t, "HELLO WORLD", w.Body.String())
+
+	var rw http.ResponseWriter = newResponseWriter(httptest.NewRecorder())
+	_, isFlusher := rw.(http.Flusher)
+	_, isHijacker := rw.(http.Hijacker)
+	assert.True(t, isFlusher)
+	assert.True(t, isHijacker)
+}
+
+func TestRecover(t *testing.T) {
+	app := New()
+	app.Get("/panic", func(c Ctx) error {
+		c.Set("user", "john")
+		panic("boom")
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	r.Header.Set("Accept", "text/html")
+
+	w := httptest.NewRecorder()
+	app.server.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "panic: boom")
+	assert.Contains(t, w.Body.String(), "server_test.go")
+	assert.Contains(t, w.Body.String(), "john")
+
+	w = httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "panic: boom")
+
+	prod := New(&Config{Mode: Production})
+	prod.Get("/panic", func(c Ctx) error {
+		panic(errTestHandler)
+	})
+
+	var reported error
+	prod.OnError(func(c Ctx, err error) {
+		reported = err
+	})
+
+	w = httptest.NewRecorder()
+	prod.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), errTestHandler.Error())
+
+	var panicErr *PanicError
+	if assert.ErrorAs(t, reported, &panicErr) {
+		assert.ErrorIs(t, reported, errTestHandler)
+		assert.NotEmpty(t, panicErr.Stack)
+		assert.Contains(t, panicErr.Stack[0].File, "server_test.go")
+	}
+
+	prod.Get("/partial", func(c Ctx) error {
+		c.Header().Add("ETag", `"partial"`)
+		c.Response().Header().Set("Set-Cookie", "partial=1")
+		c.ContentType(ContentTypeText).SendString("partial")
+		panic("boom")
+	}, func(c Ctx) error {
+		c.Response().Buffer()
+		return c.Next()
+	})
+
+	w = httptest.NewRecorder()
+	prod.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/partial", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, ContentTypeJSON, w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.Empty(t, w.Header().Get("Set-Cookie"))
+	assert.NotEmpty(t, w.Header().Get("X-Request-ID"))
+	assert.NotContains(t, w.Body.String(), "partial")
+	assert.Contains(t, w.Body.String(), `"error":"Internal Server Error"`)
+}
+
+func TestLogging(t *testing.T) {
+	var logs, access bytes.Buffer
+
+	app := New(&Config{
+		Logger: slog.New(slog.NewJSONHandler(&logs, nil)),
+	})
+
+	var outerErr error
+	api := app.Group("/", func(c Ctx) error {
+		if outerErr = c.Next(); outerErr != nil {
+			return fmt.Errorf("outer: %w", outerErr)
+		}
+		return nil
+	}, AccessLog(&access, CommonLogFormat))
+	api.Get("/hello", func(c Ctx) error {
+		c.Logger().Info("hello")
+		return c.SendString("hello")
+	}).Name("hello")
+	api.Get("/fail", func(c Ctx) error {
+		return NewError(http.StatusTeapot, "teapot")
+	})
+
+	w := httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/hello", nil))
+
+	var entry map[string]any
+	assert.Nil(t, json.NewDecoder(&logs).Decode(&entry))
+	assert.Equal(t, "hello", entry["msg"])
+	assert.Equal(t, "hello", entry["route"])
+	assert.Equal(t, "/hello", entry["path"])
+	assert.NotEmpty(t, entry["request_id"])
+
+	assert.Regexp(t, `^192\.0\.2\.1 - - \[.+\] "GET /hello HTTP/1\.1" 200 5\n$`, access.String())
+
+	access.Reset()
+	w = httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Contains(t, access.String(), `"GET /fail HTTP/1.1" 418`)
+	assert.Equal(t, 1, strings.Count(access.String(), "\n"))
+	assert.Equal(t, 1, strings.Count(w.Body.String(), "teapot"))
+	assert.EqualError(t, outerErr, "teapot")
+
+	var appAccess bytes.Buffer
+	app.AccessLog(&appAccess, CommonLogFormat)
+
+	app.server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	app.server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/hello", nil))
+	app.server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodOptions, "/hello", nil))
+	assert.Contains(t, appAccess.String(), `"GET /missing HTTP/1.1" 404`)
+	assert.Contains(t, appAccess.String(), `"POST /hello HTTP/1.1" 405`)
+	assert.Contains(t, appAccess.String(), `"OPTIONS /hello HTTP/1.1" 204`)
+
+	var console bytes.Buffer
+	logger := slog.New(NewConsoleHandler(&console, nil))
+	logger.Info("request", "method", "GET", "path", "/hello", "duration", time.Millisecond)
+	assert.Contains(t, console.String(), "/hello")
+	assert.Contains(t, console.String(), "1ms")
+}
+
+func TestRequestID(t *testing.T) {
+	app := New()
+
+	app.Get("/", func(c Ctx) error {
+		id, _ := RequestIDFromContext(c.Context())
+		return c.SendString(c.ID() + " " + id)
+	})
+
+	w := httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	id := w.Header().Get("X-Request-ID")
+	assert.NotEmpty(t, id)
+	assert.Equal(t, id+" "+id, w.Body.String())
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Request-ID", "upstream-42")
+	w = httptest.NewRecorder()
+	app.server.ServeHTTP(w, r)
+	assert.Equal(t, "upstream-42", w.Header().Get("X-Request-ID"))
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Request-ID", "<script>")
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w = httptest.NewRecorder()
+	app.server.ServeHTTP(w, r)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", w.Header().Get("X-Request-ID"))
+
+	w = httptest.NewRecorder()
+	app.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	var body Map
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, w.Header().Get("X-Request-ID"), body["request_id"])
+
+	ulid := New(&Config{RequestID: &RequestIDConfig{Generator: ULIDRequestID}})
+	ulid.Get("/", func(c Ctx) error { return c.SendString(c.ID()) })
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Request-ID", "upstream-43")
+	w = httptest.NewRecorder()
+	ulid.server.ServeHTTP(w, r)
+	assert.Equal(t, "upstream-43", w.Body.String())
+
+	counter := New(&Config{RequestID: &RequestIDConfig{IgnoreHeader: true, Generator: CounterRequestID()}})
+	counter.Get("/", func(c Ctx) error { return c.SendString(c.ID()) })
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Request-ID", "ignored")
+	w = httptest.NewRecorder()
+	counter.server.ServeHTTP(w, r)
+	assert.Regexp(t, `^[0-9a-f]{8}-1$`, w.Body.String())
+
+	assert.Regexp(t, `^[0-9A-HJKMNP-TV-Z]{26}$`, ULIDRequestID())
+}
@@ -0,0 +1,112 @@
+package gale
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/signal"
+)
+
+// Shutdown gracefully stops the Gale application.
+// It runs the OnShutdown hooks, stops accepting new connections, closes the websocket servers, waits for the in-flight
+// requests and streams to finish and closes the session store if it implements io.Closer.
+// If the context expires before the shutdown is complete, the context error is returned.
+// The OnShutdown hooks of the mounted applications run before the ones of the parent, in reverse mount order.
+func (g *Gale) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	if err := g.runShutdownHooks(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := g.stop(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// stop stops the servers, waits for the in-flight requests and closes the resources of the application
+// and the mounted applications, without running the OnShutdown hooks
+func (g *Gale) stop(ctx context.Context) error {
+	var errs []error
+
+	g.mu.Lock()
+	servers := g.httpServers
+	wsServers := make([]WSServer, 0, len(g.wsServers))
+	for s := range g.wsServers {
+		wsServers = append(wsServers, s)
+	}
+	g.mu.Unlock()
+
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// hijacked websocket connections are not tracked by the http.Server
+	for _, s := range wsServers {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		g.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, ctx.Err())
+	}
+
+	if closer, ok := g.config.Session.Store.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, sub := range g.mountedApps() {
+		if err := sub.stop(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// handleShutdownSignals shuts down the application when one of the configured signals is received
+func (g *Gale) handleShutdownSignals() {
+	conf := g.config.Server
+	if len(conf.ShutdownSignals) == 0 {
+		return
+	}
+
+	g.signalOnce.Do(func() {
+		ctx, stop := signal.NotifyContext(context.Background(), conf.ShutdownSignals...)
+
+		go func() {
+			defer stop()
+			<-ctx.Done()
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+			defer cancel()
+
+			if err := g.Shutdown(shutdownCtx); err != nil {
+				g.config.Logger.Error("shutdown failed", "error", err)
+			}
+		}()
+	})
+}
+
+// registerWSServer adds a websocket server to the application, it is closed on shutdown
+func (g *Gale) registerWSServer(s WSServer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.wsServers[s] = struct{}{}
+}
@@ -21,6 +21,7 @@ type MemoryStore struct {
 	mu         sync.RWMutex
 	gcInterval time.Duration
 	done       chan struct{}
+	closeOnce  sync.Once
 }
 
 type memStoreEntry struct {
@@ -28,6 +29,10 @@ type memStoreEntry struct {
 	exp  *time.Time
 }
 
+func (e memStoreEntry) expired() bool {
+	return e.exp != nil && e.exp.Before(time.Now())
+}
+
 // NewMemStorage creates a new MemoryStore.
 func NewMemStorage(gcInterval ...time.Duration) SessionStore {
 	var duration time.Duration
@@ -47,8 +52,11 @@ func NewMemStorage(gcInterval ...time.Duration) SessionStore {
 	return m
 }
 
+// Close stops the garbage collector of the store.
 func (s *MemoryStore) Close() error {
-	s.done <- struct{}{}
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
 	return nil
 }
 
@@ -56,7 +64,7 @@ func (s *MemoryStore) Get(key string) ([]byte, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if v, ok := s.data[key]; ok {
+	if v, ok := s.data[key]; ok && !v.expired() {
 		return v.data, nil
 	}
 	return nil, errors.New("key not found")
@@ -66,8 +74,8 @@ func (s *MemoryStore) Exists(key string) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	_, ok := s.data[key]
-	return ok
+	v, ok := s.data[key]
+	return ok && !v.expired()
 }
 
 func (s *MemoryStore) Set(key string, value []byte) error {
@@ -104,13 +112,13 @@ func (s *MemoryStore) gc() {
 		case <-s.done:
 			return
 		case <-ticker.C:
+			s.mu.Lock()
 			for k, v := range s.data {
-				if v.exp != nil && v.exp.Before(time.Now()) {
-					s.mu.Lock()
+				if v.expired() {
 					delete(s.data, k)
-					s.mu.Unlock()
 				}
 			}
+			s.mu.Unlock()
 		}
 	}
 }
@@ -1,9 +1,12 @@
 package gale
 
+import "context"
+
 // HandlerFunc is a function that handles a request.
 type HandlerFunc func(c Ctx) error
 
 // MiddlewareFunc is a function that is executed before the handler.
+// It can call c.Next() to run the rest of the chain and continue after the handler returned.
 type MiddlewareFunc func(c Ctx) error
 
 // WSHandlerFunc is a function that handles a WebSocket request.
@@ -12,6 +15,29 @@ type WSHandlerFunc func(conn WSConn)
 // RouteParamValidatorFunc is a function that validates a route parameter.
 type RouteParamValidatorFunc func(value string) (string, error)
 
+// RouteParamValidatorFactory creates a route parameter validator from its arguments,
+// like "1-64" in {slug@len:1-64}. It is called once when the route is registered.
+type RouteParamValidatorFactory func(args string) (RouteParamValidatorFunc, error)
+
+// ValidationRuleFunc validates a struct field with the parameter of the rule, like "3" in `validate:"min=3"`.
+// The returned error is the message of the field, like "must be even".
+type ValidationRuleFunc func(value any, param string) error
+
+// StartupHookFunc is executed when the server starts listening on the address.
+type StartupHookFunc func(addr string) error
+
+// ShutdownHookFunc is executed before the application shuts down.
+type ShutdownHookFunc func(ctx context.Context) error
+
+// RouteHookFunc is executed when a route is registered.
+type RouteHookFunc func(route Route)
+
+// ErrorHookFunc is executed with every error that reaches the error handler.
+type ErrorHookFunc func(c Ctx, err error)
+
+// RequestIDGenerator generates a new request id.
+type RequestIDGenerator func() string
+
 type UseExtension interface {
 	Register(g *Gale)
 }
@@ -1,6 +1,7 @@
 package gale
 
 import (
+	"context"
 	"embed"
 	"encoding/base64"
 	"encoding/json"
@@ -47,6 +48,12 @@ func (u *UI) Register(g *Gale) {
 	u.registerRoutes()
 
 	g.Hook(PostRequestHook, u.handleLogPaths)
+	g.OnShutdown(func(ctx context.Context) error {
+		if closer, ok := u.store.(io.Closer); ok {
+			return closer.Close()
+		}
+		return nil
+	})
 }
 
 func (u *UI) registerRoutes() {
@@ -177,20 +184,25 @@ func (u *UI) render(c Ctx, page string, props Map) error {
 	props["StylePath"] = filepath.Join(u.prefix, "style.css")
 	props["Prefix"] = u.prefix
 
+	return renderUIPage(c.ResponseWriter(), page, props)
+}
+
+// renderUIPage renders a devtools page into the layout
+func renderUIPage(w io.Writer, page string, props Map) error {
 	tmpl, err := template.New("layout").ParseFS(uiFiles, "ui/templates/layout.html")
 	if err != nil {
 		return err
 	}
 
-	tmpl, err = tmpl.New("content").Funcs(u.getFuncMap()).ParseFS(uiFiles, fmt.Sprintf("ui/templates/pages/%s.html", page))
+	tmpl, err = tmpl.New("content").Funcs(uiFuncMap()).ParseFS(uiFiles, fmt.Sprintf("ui/templates/pages/%s.html", page))
 	if err != nil {
 		return err
 	}
 
-	return tmpl.ExecuteTemplate(c.ResponseWriter(), "layout", props)
+	return tmpl.ExecuteTemplate(w, "layout", props)
 }
 
-func (u *UI) getFuncMap() template.FuncMap {
+func uiFuncMap() template.FuncMap {
 	return template.FuncMap{
 		"toLower": strings.ToLower,
 		"getFuncName": func(fn any) string {
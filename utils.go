@@ -57,6 +57,8 @@ func colorMethodName(method string) string {
 func logRoutes(routes []Route) {
 	for _, route := range routes {
 		for _, p := range route.NormalizedPaths() {
+			p = route.Host() + p
+
 			method, l := methodSpaces(route.Method())
 			colorMethod := colorMethodName(method)
 			mDots := strings.Repeat(".", l)
@@ -0,0 +1,362 @@
+package gale
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// fieldNameTags are the tags that name a field in the validation errors, the first one found is used
+var fieldNameTags = []string{"json", "xml", "form", "query", "param", "header", "cookie"}
+
+// validator validates structs by their validate tags, like `validate:"required,min=3"`
+type validator struct {
+	mu    sync.RWMutex
+	rules map[string]ValidationRuleFunc
+	// fields caches the parsed rules of the struct types
+	fields sync.Map
+}
+
+// fieldRules are the parsed validate tag of a struct field
+type fieldRules struct {
+	index  int
+	name   string
+	source string
+	// omitEmpty skips the rules of zero values, like `validate:"omitempty,min=3"`
+	omitEmpty bool
+	rules     []fieldRule
+}
+
+type fieldRule struct {
+	param string
+	fn    ValidationRuleFunc
+}
+
+func newValidator() *validator {
+	return &validator{
+		rules: map[string]ValidationRuleFunc{
+			"required":     ruleRequired,
+			"min":          ruleMin,
+			"max":          ruleMax,
+			"len":          ruleLen,
+			"oneof":        ruleOneOf,
+			"email":        ruleEmail,
+			"url":          ruleURL,
+			"uuid":         ruleUUID,
+			"alpha":        ruleAlpha,
+			"alphanumeric": ruleAlphaNumeric,
+		},
+	}
+}
+
+// RegisterValidationRule registers a custom rule for the validate struct tags, like `validate:"even"`.
+// The rule gets the field value and the parameter of the rule, like "3" in `validate:"min=3"`.
+func (g *Gale) RegisterValidationRule(name string, fn ValidationRuleFunc) {
+	g.validator.mu.Lock()
+	defer g.validator.mu.Unlock()
+
+	if _, ok := g.validator.rules[name]; ok {
+		g.errs = append(g.errs, fmt.Errorf("validation rule \"%s\" already exists", name))
+		return
+	}
+	g.validator.rules[name] = fn
+
+	// the cached types may refer to the new rule
+	g.validator.fields.Range(func(key, _ any) bool {
+		g.validator.fields.Delete(key)
+		return true
+	})
+}
+
+// validate checks the fields of the struct by their validate tags and returns a 422 error with the invalid fields.
+// Values that are not structs or pointers to structs are not validated, unknown rules are returned as an error.
+func (v *validator) validate(data any) error {
+	rv := reflect.ValueOf(data)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	errs, err := v.validateStruct(rv, "")
+	if err != nil {
+		return err
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Field + " " + e.Message
+	}
+
+	return &Error{
+		Err:    "validation failed: " + strings.Join(msgs, "; "),
+		Status: http.StatusUnprocessableEntity,
+		Fields: errs,
+	}
+}
+
+func (v *validator) validateStruct(rv reflect.Value, prefix string) ([]FieldError, error) {
+	var errs []FieldError
+
+	fields, err := v.structRules(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		fv := rv.Field(field.index)
+		path := prefix + field.name
+
+		for _, rule := range field.rules {
+			if field.omitEmpty && fv.IsZero() {
+				break
+			}
+
+			if err := rule.fn(fv.Interface(), rule.param); err != nil {
+				errs = append(errs, FieldError{Field: path, Source: field.source, Message: err.Error()})
+				break
+			}
+		}
+
+		nested, err := v.validateNested(fv, path)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, nested...)
+	}
+
+	return errs, nil
+}
+
+// validateNested validates the nested structs, and the structs in slices, like "items[0].name"
+func (v *validator) validateNested(fv reflect.Value, path string) ([]FieldError, error) {
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil, nil
+		}
+		fv = fv.Elem()
+	}
+
+	switch {
+	case fv.Kind() == reflect.Struct && isNestedStruct(fv.Type()):
+		return v.validateStruct(fv, path+".")
+	case fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array:
+		var errs []FieldError
+		for i := 0; i < fv.Len(); i++ {
+			nested, err := v.validateNested(fv.Index(i), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			errs = append(errs, nested...)
+		}
+		return errs, nil
+	}
+
+	return nil, nil
+}
+
+// structRules returns the parsed rules of the exported fields, they are parsed once per type
+func (v *validator) structRules(t reflect.Type) ([]fieldRules, error) {
+	if cached, ok := v.fields.Load(t); ok {
+		return cached.([]fieldRules), nil
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var fields []fieldRules
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fr := fieldRules{index: i, name: field.Name}
+		for _, tag := range fieldNameTags {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name != "" && name != "-" {
+				fr.name, fr.source = name, tag
+				break
+			}
+		}
+
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			for _, rule := range strings.Split(tag, ",") {
+				name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+				if name == "omitempty" {
+					fr.omitEmpty = true
+					continue
+				}
+
+				fn, ok := v.rules[name]
+				if !ok {
+					return nil, fmt.Errorf("validation rule '%s' of %s.%s does not exist", name, t.Name(), field.Name)
+				}
+				fr.rules = append(fr.rules, fieldRule{param: param, fn: fn})
+			}
+		}
+
+		fields = append(fields, fr)
+	}
+
+	v.fields.Store(t, fields)
+	return fields, nil
+}
+
+// size returns the number of characters of strings, the length of slices and maps, and the value of numbers
+func size(value any) (float64, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(rv.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(rv.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return 0, false
+		}
+		return size(rv.Elem().Interface())
+	}
+	return 0, false
+}
+
+// sizeUnit describes what size measures for the error messages
+func sizeUnit(value any) string {
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return " items"
+	}
+	return ""
+}
+
+// text returns the string form of the value for the text rules, pointers are dereferenced and nil is empty
+func text(value any) string {
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+
+	if !rv.IsValid() {
+		return ""
+	}
+	if rv.Kind() == reflect.String {
+		return rv.String()
+	}
+	return fmt.Sprint(rv.Interface())
+}
+
+func ruleRequired(value any, _ string) error {
+	if reflect.ValueOf(value).IsZero() {
+		return errors.New("is required")
+	}
+	return nil
+}
+
+func ruleMin(value any, param string) error {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("has an invalid min rule '%s'", param)
+	}
+
+	if n, ok := size(value); !ok || n < limit {
+		return fmt.Errorf("must be at least %s%s", param, sizeUnit(value))
+	}
+	return nil
+}
+
+func ruleMax(value any, param string) error {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("has an invalid max rule '%s'", param)
+	}
+
+	if n, ok := size(value); !ok || n > limit {
+		return fmt.Errorf("must be at most %s%s", param, sizeUnit(value))
+	}
+	return nil
+}
+
+func ruleLen(value any, param string) error {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("has an invalid len rule '%s'", param)
+	}
+
+	if n, ok := size(value); !ok || n != limit {
+		return fmt.Errorf("must be exactly %s%s", param, sizeUnit(value))
+	}
+	return nil
+}
+
+// ruleOneOf accepts one of the space separated values, like `validate:"oneof=asc desc"`
+func ruleOneOf(value any, param string) error {
+	if !slices.Contains(strings.Fields(param), text(value)) {
+		return fmt.Errorf("must be one of %s", param)
+	}
+	return nil
+}
+
+func ruleEmail(value any, _ string) error {
+	s := text(value)
+	if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+		return errors.New("must be a valid email address")
+	}
+	return nil
+}
+
+func ruleURL(value any, _ string) error {
+	s := text(value)
+	if u, err := url.Parse(s); err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.New("must be a valid URL")
+	}
+	return nil
+}
+
+func ruleUUID(value any, _ string) error {
+	if _, err := validateUUIDv4(text(value)); err != nil {
+		return errors.New("must be a valid UUID")
+	}
+	return nil
+}
+
+func ruleAlpha(value any, _ string) error {
+	if _, err := validateAlpha(text(value)); err != nil {
+		return errors.New("must contain only letters")
+	}
+	return nil
+}
+
+func ruleAlphaNumeric(value any, _ string) error {
+	if _, err := validateAlphaNumeric(text(value)); err != nil {
+		return errors.New("must contain only letters and numbers")
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package gale
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	type item struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	type request struct {
+		Name  string   `json:"name" validate:"required,min=3,max=8"`
+		Email string   `json:"email" validate:"required,email"`
+		Sort  string   `json:"sort" validate:"oneof=asc desc"`
+		Age   int      `json:"age" validate:"min=18"`
+		Tags  []string `json:"tags" validate:"max=2"`
+		Count int      `json:"count" validate:"even"`
+		Items []item   `json:"items"`
+		Note  string
+	}
+
+	app := New()
+	app.RegisterValidationRule("even", func(value any, _ string) error {
+		if value.(int)%2 != 0 {
+			return errors.New("must be even")
+		}
+		return nil
+	})
+
+	app.Post("/", func(c Ctx) error {
+		var req request
+		if err := c.Body().Parse(&req); err != nil {
+			return err
+		}
+		return c.SendString("ok")
+	})
+
+	send := func(body, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", ContentTypeJSON)
+		r.Header.Set("Accept", accept)
+
+		w := httptest.NewRecorder()
+		app.server.ServeHTTP(w, r)
+		return w
+	}
+
+	w := send(`{"name":"John","email":"john@example.com","sort":"asc","age":20,"tags":["a"],"count":2}`, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	invalid := `{"name":"Jo","sort":"up","age":12,"tags":["a","b","c"],"count":3,"items":[{"name":"x"},{}]}`
+
+	w = send(invalid, ContentTypeJSON)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var body struct {
+		Fields []FieldError `json:"fields"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, []FieldError{
+		{Field: "name", Source: "json", Message: "must be at least 3 characters"},
+		{Field: "email", Source: "json", Message: "is required"},
+		{Field: "sort", Source: "json", Message: "must be one of asc desc"},
+		{Field: "age", Source: "json", Message: "must be at least 18"},
+		{Field: "tags", Source: "json", Message: "must be at most 2 items"},
+		{Field: "count", Source: "json", Message: "must be even"},
+		{Field: "items[1].name", Source: "json", Message: "is required"},
+	}, body.Fields)
+
+	w = send(invalid, ContentTypeXML)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var xmlBody struct {
+		Fields []FieldError `xml:"fields>field"`
+	}
+	assert.Nil(t, xml.Unmarshal(w.Body.Bytes(), &xmlBody))
+	assert.Len(t, xmlBody.Fields, 7)
+
+	w = send(invalid, ContentTypeText)
+	assert.Contains(t, w.Body.String(), "items[1].name: is required")
+
+	type zero struct {
+		Count    int    `json:"count" validate:"min=1"`
+		Offset   int    `json:"offset" validate:"max=-1"`
+		Nickname string `json:"nickname" validate:"omitempty,min=3"`
+	}
+
+	var e *Error
+	if assert.ErrorAs(t, app.validator.validate(&zero{}), &e) {
+		assert.Equal(t, []FieldError{
+			{Field: "count", Source: "json", Message: "must be at least 1"},
+			{Field: "offset", Source: "json", Message: "must be at most -1"},
+		}, e.Fields)
+	}
+
+	type pointers struct {
+		Email *string `json:"email" validate:"email"`
+		URL   *string `json:"url" validate:"url"`
+		Sort  *string `json:"sort" validate:"omitempty,oneof=asc desc"`
+	}
+
+	email, url, sort := "john@example.com", "https://example.com", "asc"
+	assert.Nil(t, app.validator.validate(&pointers{Email: &email, URL: &url, Sort: &sort}))
+
+	email, url, sort = "john", "example", "up"
+	if assert.ErrorAs(t, app.validator.validate(&pointers{Email: &email, URL: &url, Sort: &sort}), &e) {
+		assert.Equal(t, []FieldError{
+			{Field: "email", Source: "json", Message: "must be a valid email address"},
+			{Field: "url", Source: "json", Message: "must be a valid URL"},
+			{Field: "sort", Source: "json", Message: "must be one of asc desc"},
+		}, e.Fields)
+	}
+
+	type typo struct {
+		Name string `json:"name" validate:"requird"`
+	}
+
+	err := app.validator.validate(&typo{})
+	assert.ErrorContains(t, err, "'requird'")
+	assert.False(t, errors.As(err, &e))
+
+	app.RegisterValidationRule("even", nil)
+	assert.Error(t, app.Validate())
+}
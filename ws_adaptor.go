@@ -1,7 +1,6 @@
 package gale
 
 import (
-	"log"
 	"strings"
 	"time"
 
@@ -13,7 +12,7 @@ func wsHandler(fn WSHandlerFunc) HandlerFunc {
 		start := time.Now()
 
 		if strings.ToLower(c.Header().Get("Upgrade")) != "websocket" {
-			log.Println("websocket: request is not a websocket handshake")
+			c.Logger().Warn("websocket: request is not a websocket handshake")
 			return nil
 		}
 
@@ -21,11 +20,11 @@ func wsHandler(fn WSHandlerFunc) HandlerFunc {
 
 		conn, err := websocket.Accept(w, r, c.App().config.WebSocket)
 		if err != nil {
-			log.Println("websocket: failed to accept connection: ", err)
+			c.Logger().Error("websocket: failed to accept connection", "error", err)
 			return nil
 		}
 
-		serverLogger(start, "WS", c.IP())
+		c.App().config.Logger.Info("request", "method", "WS", "path", c.Path(), "duration", time.Since(start))
 
 		newConn := NewWSConn(c, conn)
 		fn(newConn)
@@ -3,10 +3,11 @@ package gale
 import (
 	"context"
 	"encoding/json"
+	"sync"
 	"time"
 
 	"github.com/coder/websocket"
-	"github.com/fatih/color"
+	"github.com/google/uuid"
 )
 
 // Interfaces
@@ -52,12 +53,13 @@ type socketConn struct {
 	ctx  Ctx
 	conn *websocket.Conn
 
-	quitch chan struct{}
+	quitch    chan struct{}
+	closeOnce sync.Once
 }
 
 func NewWSConn(ctx Ctx, conn *websocket.Conn) WSConn {
 	return &socketConn{
-		id:     ctx.ID(),
+		id:     uuid.New().String(),
 		ctx:    ctx,
 		conn:   conn,
 		quitch: make(chan struct{}),
@@ -77,8 +79,12 @@ func (c *socketConn) Conn() *websocket.Conn {
 }
 
 func (c *socketConn) Close() error {
-	c.quitch <- struct{}{}
-	return c.conn.Close(websocket.StatusGoingAway, "Closed by server.")
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.quitch)
+		err = c.conn.Close(websocket.StatusGoingAway, "Closed by server.")
+	})
+	return err
 }
 
 func (c *socketConn) Send(m []byte) error {
@@ -123,7 +129,7 @@ func (c *socketConn) readLoop(s WSServer) {
 			}
 
 			if t != websocket.MessageText {
-				color.Red("Unsupported message type, please send string")
+				c.ctx.Logger().Warn("websocket: unsupported message type, please send string")
 				continue
 			}
 
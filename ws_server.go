@@ -56,7 +56,8 @@ type socketServer struct {
 	msgInCh  chan WSMessage
 	msgOutCh chan *broadcastMessage
 
-	mu sync.RWMutex
+	mu        sync.RWMutex
+	closeOnce sync.Once
 }
 
 // NewWebSocketServer creates a new websocket server.
@@ -95,13 +96,15 @@ func (s *socketServer) AddConn(conn WSConn) {
 
 	s.conns[conn.ID()] = conn
 	go conn.readLoop(s)
+
+	// the server is closed when the application shuts down
+	if ctx := conn.Ctx(); ctx != nil && ctx.App() != nil {
+		ctx.App().registerWSServer(s)
+	}
 }
 
 func (s *socketServer) RemoveConn(conn WSConn, close ...bool) error {
-	var closeConn bool
-	if len(close) == 0 || close[0] {
-		closeConn = close[0]
-	}
+	closeConn := len(close) == 0 || close[0]
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -141,14 +144,22 @@ func (s *socketServer) BroadcastTo(content []byte, conns ...WSConn) {
 }
 
 func (s *socketServer) Close() error {
-	s.quitch <- struct{}{}
+	s.closeOnce.Do(func() {
+		close(s.quitch)
+	})
 
 	s.mu.Lock()
+	conns := make([]WSConn, 0, len(s.conns))
 	for _, conn := range s.conns {
-		s.RemoveConn(conn, true)
+		conns = append(conns, conn)
 	}
+	s.conns = make(map[string]WSConn)
 	s.mu.Unlock()
 
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+
 	return nil
 }
 